@@ -17,6 +17,8 @@ limitations under the License.
 package aes128
 
 import (
+	utils "circuits/utils"
+
 	"github.com/consensys/gnark/frontend"
 )
 
@@ -43,8 +45,11 @@ func (circuit *GCMWrapper) Define(api frontend.API) error {
 	return nil
 }
 
+// block cipher, the expanded key can be shared by every block encrypted under the key
 type AES interface {
 	Encrypt(key [16]frontend.Variable, pt [16]frontend.Variable) [16]frontend.Variable
+	ExpandKey(key [16]frontend.Variable) [176]frontend.Variable
+	EncryptExpanded(expandedKey [176]frontend.Variable, pt [16]frontend.Variable) [16]frontend.Variable
 }
 
 func NewGCM(api frontend.API, aes AES) GCM {
@@ -56,32 +61,55 @@ type GCM struct {
 	aes AES
 }
 
-// aes gcm encryption
+// aes gcm encryption, a trailing partial block is verified bytewise
 func (gcm *GCM) Assert(key [16]frontend.Variable, iv [12]frontend.Variable, chunkIndex frontend.Variable, plaintext, ciphertext []frontend.Variable, sequenceNumber [8]frontend.Variable) {
+	gcm.assert(gcm.aes.ExpandKey(key), iv, chunkIndex, plaintext, ciphertext, nil, sequenceNumber)
+}
+
+// aes gcm encryption under already expanded round keys, e.g. shared with the tag of the record
+func (gcm *GCM) AssertExpanded(expandedKey [176]frontend.Variable, iv [12]frontend.Variable, chunkIndex frontend.Variable, plaintext, ciphertext []frontend.Variable, sequenceNumber [8]frontend.Variable) {
+	gcm.assert(expandedKey, iv, chunkIndex, plaintext, ciphertext, nil, sequenceNumber)
+}
+
+// aes gcm encryption of the first length bytes, plaintext and ciphertext are sized for the maximum length
+// bytes from length on must be zero in both
+func (gcm *GCM) AssertMasked(key [16]frontend.Variable, iv [12]frontend.Variable, chunkIndex frontend.Variable, plaintext, ciphertext []frontend.Variable, length frontend.Variable, sequenceNumber [8]frontend.Variable) {
+	mask := utils.PrefixMask(gcm.api, length, len(plaintext))
+	gcm.assert(gcm.aes.ExpandKey(key), iv, chunkIndex, plaintext, ciphertext, mask, sequenceNumber)
+}
+
+// one key schedule for all counter blocks
+func (gcm *GCM) assert(expandedKey [176]frontend.Variable, iv [12]frontend.Variable, chunkIndex frontend.Variable, plaintext, ciphertext, mask []frontend.Variable, sequenceNumber [8]frontend.Variable) {
 
 	inputSize := len(plaintext)
-	numberBlocks := int(inputSize / 16)
+	numberBlocks := (inputSize + 15) / 16
 	var epoch int
 	for epoch = 0; epoch < numberBlocks; epoch++ {
 
 		idx := gcm.api.Add(chunkIndex, frontend.Variable(epoch))
 		eIndex := epoch * 16
+		blockSize := min(16, inputSize-eIndex)
 
 		var ptBlock [16]frontend.Variable
-		var ctBlock [16]frontend.Variable
-
 		for j := 0; j < 16; j++ {
-			ptBlock[j] = plaintext[eIndex+j]
-			ctBlock[j] = ciphertext[eIndex+j]
+			ptBlock[j] = 0
 		}
+		copy(ptBlock[:], plaintext[eIndex:eIndex+blockSize])
 
 		ivCounter := gcm.GetIVTLS13(iv, idx, sequenceNumber)
-		intermediate := gcm.aes.Encrypt(key, ivCounter)
+		intermediate := gcm.aes.EncryptExpanded(expandedKey, ivCounter)
 		ct := gcm.Xor16(intermediate, ptBlock)
 
 		// check ciphertext to plaintext constraints
-		for i := 0; i < 16; i++ {
-			gcm.api.AssertIsEqual(ctBlock[i], ct[i])
+		for i := 0; i < blockSize; i++ {
+			if mask == nil {
+				gcm.api.AssertIsEqual(ciphertext[eIndex+i], ct[i])
+				continue
+			}
+			// masked bytes are zero
+			m := mask[eIndex+i]
+			gcm.api.AssertIsEqual(ciphertext[eIndex+i], gcm.api.Mul(m, ct[i]))
+			gcm.api.AssertIsEqual(gcm.api.Mul(gcm.api.Sub(1, m), plaintext[eIndex+i]), 0)
 		}
 	}
 }
@@ -105,27 +133,21 @@ func (gcm *GCM) GetIV(nonce [12]frontend.Variable, ctr frontend.Variable) [16]fr
 	return out
 }
 
-// TODO - pass sequence number through the circuit! -> Should be an input
 func (gcm *GCM) GetIVTLS13(write_iv [12]frontend.Variable, ctr frontend.Variable, seq [8]frontend.Variable) [16]frontend.Variable {
 
 	var out [16]frontend.Variable
 	var i int
 
-	var seqNumberVars [8]frontend.Variable
-	for j := 0; j < 8; j++ {
-		seqNumberVars[j] = gcm.api.FromBinary(seq[j])
-	}
-
-	// Step 3: Pad the sequence number
+	// left pad the big endian sequence number bytes to the iv length
 	var seqNumberPadded [12]frontend.Variable
 	for i := 0; i < 4; i++ {
-		seqNumberPadded[i] = gcm.api.FromBinary(0) // Padded with zeros at the beginning
+		seqNumberPadded[i] = 0
 	}
-	copy(seqNumberPadded[4:], seqNumberVars[:])
+	copy(seqNumberPadded[4:], seq[:])
 
 	var nonce [12]frontend.Variable
 	for i := 0; i < len(nonce); i++ {
-		nonce[i] = gcm.variableXor(seqNumberPadded[i], write_iv[i], 12)
+		nonce[i] = gcm.variableXor(seqNumberPadded[i], write_iv[i], 8)
 	}
 
 	for i = 0; i < len(nonce); i++ {
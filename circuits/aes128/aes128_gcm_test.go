@@ -2,10 +2,13 @@ package aes128
 
 import (
 	utils "circuits/utils"
+	"crypto/aes"
+	"crypto/cipher"
 	"encoding/hex"
 	"encoding/json"
 	"testing"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/test"
@@ -147,3 +150,61 @@ func TestAES128GCMProving(t *testing.T) {
 	// Proof successfully generated
 	assert.ProverSucceeded(&circuit, &assignment)
 }
+
+// Test for Solving of a record with a multi byte sequence number against crypto/cipher
+func TestAES128GCMSequenceNumber(t *testing.T) {
+
+	key, _ := hex.DecodeString("388ba3e1baea1a4c531db91b631d69c8")
+	iv, _ := hex.DecodeString("f3e113c7fc4206b0410d1125")
+	plaintext := []byte(`{"data":{"amount":{"value":"38002.20"},"currency":"EUR"}}`)[:48]
+	seq := uint64(0x01a2b3c4d5e6f708)
+
+	// per record nonce, iv xor the left padded big endian sequence number
+	nonce := append([]byte{}, iv...)
+	for i := 0; i < 8; i++ {
+		nonce[4+i] ^= byte(seq >> (56 - 8*i))
+	}
+	block, _ := aes.NewCipher(key)
+	aead, _ := cipher.NewGCM(block)
+	ciphertext := aead.Seal(nil, nonce, plaintext, nil)
+
+	// second and third block, counter 1 is reserved for the tag
+	chunkIndex := 3
+	pt := plaintext[16:48]
+	ct := ciphertext[16:48]
+
+	assignment := GCMWrapper{
+		PlainChunks:  make([]frontend.Variable, len(pt)),
+		CipherChunks: make([]frontend.Variable, len(ct)),
+		ChunkIndex:   chunkIndex,
+	}
+	for i := range pt {
+		assignment.PlainChunks[i] = pt[i]
+		assignment.CipherChunks[i] = ct[i]
+	}
+	for i := range key {
+		assignment.Key[i] = key[i]
+	}
+	for i := range iv {
+		assignment.Iv[i] = iv[i]
+	}
+	for i := 0; i < 8; i++ {
+		assignment.SequenceNumber[i] = byte(seq >> (56 - 8*i))
+	}
+
+	circuit := GCMWrapper{
+		PlainChunks:  make([]frontend.Variable, len(pt)),
+		CipherChunks: make([]frontend.Variable, len(ct)),
+		ChunkIndex:   chunkIndex,
+	}
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the chunks do not decrypt under the nonce of another record
+	assignment.SequenceNumber[7] = byte(seq) ^ 1
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected failure with a wrong sequence number")
+	}
+}
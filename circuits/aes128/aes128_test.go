@@ -124,3 +124,88 @@ func TestCompileGetConstraints(t *testing.T) {
 
 	fmt.Printf("constraints: %d\n", r1css.GetNbConstraints())
 }
+
+// several blocks under one key schedule
+type expandedWrapper struct {
+	Key    [16]frontend.Variable
+	Plain  [][16]frontend.Variable
+	Cipher [][16]frontend.Variable `gnark:",public"`
+	shared bool
+}
+
+func (circuit *expandedWrapper) Define(api frontend.API) error {
+
+	aes := NewAES128(api)
+
+	// one key expansion for all blocks, otherwise one per block
+	var expandedKey [176]frontend.Variable
+	if circuit.shared {
+		expandedKey = aes.ExpandKey(circuit.Key)
+	}
+	for i := range circuit.Plain {
+		var cipher [16]frontend.Variable
+		if circuit.shared {
+			cipher = aes.EncryptExpanded(expandedKey, circuit.Plain[i])
+		} else {
+			cipher = aes.Encrypt(circuit.Key, circuit.Plain[i])
+		}
+		for j := range cipher {
+			api.AssertIsEqual(circuit.Cipher[i][j], cipher[j])
+		}
+	}
+
+	return nil
+}
+
+func TestEncryptExpanded(t *testing.T) {
+
+	key := utils.MustHex("2872658573f95e87550cb26374e5f667")
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nbBlocks := 4
+	newCircuit := func(shared bool) expandedWrapper {
+		return expandedWrapper{
+			Plain:  make([][16]frontend.Variable, nbBlocks),
+			Cipher: make([][16]frontend.Variable, nbBlocks),
+			shared: shared,
+		}
+	}
+
+	assignment := newCircuit(true)
+	for i := range key {
+		assignment.Key[i] = key[i]
+	}
+	for i := 0; i < nbBlocks; i++ {
+		pt := make([]byte, 16)
+		pt[15] = byte(i)
+		ct := make([]byte, 16)
+		block.Encrypt(ct, pt)
+		for j := 0; j < 16; j++ {
+			assignment.Plain[i][j] = pt[j]
+			assignment.Cipher[i][j] = ct[j]
+		}
+	}
+
+	circuit := newCircuit(true)
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the key schedule is paid once instead of once per block
+	counts := map[bool]int{}
+	for _, shared := range []bool{true, false} {
+		circuit := newCircuit(shared)
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		counts[shared] = ccs.GetNbConstraints()
+	}
+	t.Logf("constraints for %d blocks: shared %d, per block %d", nbBlocks, counts[true], counts[false])
+	if counts[true] >= counts[false] {
+		t.Fatal("shared key schedule does not save constraints")
+	}
+}
@@ -17,6 +17,8 @@ limitations under the License.
 package aes128lookup
 
 import (
+	aes128 "circuits/aes128"
+
 	"github.com/consensys/gnark/frontend"
 )
 
@@ -35,131 +37,11 @@ func (circuit *GCMWrapper) Define(api frontend.API) error {
 
 	aes := NewAES128(api)
 
-	gcm := NewGCM(api, &aes)
+	// gcm mode of aes128 over the lookup based block cipher
+	gcm := aes128.NewGCM(api, &aes)
 
 	// verify aes gcm of chunks
 	gcm.Assert(circuit.Key, circuit.Iv, circuit.ChunkIndex, circuit.PlainChunks, circuit.CipherChunks, circuit.SequenceNumber)
 
 	return nil
 }
-
-type AES interface {
-	Encrypt(key [16]frontend.Variable, pt [16]frontend.Variable) [16]frontend.Variable
-}
-
-func NewGCM(api frontend.API, aes AES) GCM {
-	return GCM{api: api, aes: aes}
-}
-
-type GCM struct {
-	api frontend.API
-	aes AES
-}
-
-// aes gcm encryption
-func (gcm *GCM) Assert(key [16]frontend.Variable, iv [12]frontend.Variable, chunkIndex frontend.Variable, plaintext, ciphertext []frontend.Variable, sequenceNumber [8]frontend.Variable) {
-
-	inputSize := len(plaintext)
-	numberBlocks := int(inputSize / 16)
-	var epoch int
-	for epoch = 0; epoch < numberBlocks; epoch++ {
-
-		idx := gcm.api.Add(chunkIndex, frontend.Variable(epoch))
-		eIndex := epoch * 16
-
-		var ptBlock [16]frontend.Variable
-		var ctBlock [16]frontend.Variable
-
-		for j := 0; j < 16; j++ {
-			ptBlock[j] = plaintext[eIndex+j]
-			ctBlock[j] = ciphertext[eIndex+j]
-		}
-
-		ivCounter := gcm.GetIVTLS13(iv, idx, sequenceNumber)
-		intermediate := gcm.aes.Encrypt(key, ivCounter)
-		ct := gcm.Xor16(intermediate, ptBlock)
-
-		// check ciphertext to plaintext constraints
-		for i := 0; i < 16; i++ {
-			gcm.api.AssertIsEqual(ctBlock[i], ct[i])
-		}
-	}
-}
-
-// required for aes_gcm
-func (gcm *GCM) GetIV(nonce [12]frontend.Variable, ctr frontend.Variable) [16]frontend.Variable {
-
-	var out [16]frontend.Variable
-	var i int
-	for i = 0; i < len(nonce); i++ {
-		out[i] = nonce[i]
-	}
-	bits := gcm.api.ToBinary(ctr, 32)
-	remain := 12
-	for j := 3; j >= 0; j-- {
-		start := 8 * j
-		// little endian order chunk parsing from back to front
-		out[remain] = gcm.api.FromBinary(bits[start : start+8]...)
-		remain += 1
-	}
-	return out
-}
-
-// TODO - pass sequence number through the circuit! -> Should be an input
-func (gcm *GCM) GetIVTLS13(write_iv [12]frontend.Variable, ctr frontend.Variable, seq [8]frontend.Variable) [16]frontend.Variable {
-
-	var out [16]frontend.Variable
-	var i int
-
-	var seqNumberVars [8]frontend.Variable
-	for j := 0; j < 8; j++ {
-		seqNumberVars[j] = gcm.api.FromBinary(seq[j])
-	}
-
-	// Step 3: Pad the sequence number
-	var seqNumberPadded [12]frontend.Variable
-	for i := 0; i < 4; i++ {
-		seqNumberPadded[i] = gcm.api.FromBinary(0) // Padded with zeros at the beginning
-	}
-	copy(seqNumberPadded[4:], seqNumberVars[:])
-
-	var nonce [12]frontend.Variable
-	for i := 0; i < len(nonce); i++ {
-		nonce[i] = gcm.variableXor(seqNumberPadded[i], write_iv[i], 12)
-	}
-
-	for i = 0; i < len(nonce); i++ {
-		out[i] = nonce[i]
-	}
-
-	bits := gcm.api.ToBinary(ctr, 32)
-	remain := 12
-	for j := 3; j >= 0; j-- {
-		start := 8 * j
-		// little endian order chunk parsing from back to front
-		out[remain] = gcm.api.FromBinary(bits[start : start+8]...)
-		remain += 1
-	}
-
-	return out
-}
-
-// required for plaintext xor encrypted counter blocks
-func (gcm *GCM) Xor16(a [16]frontend.Variable, b [16]frontend.Variable) [16]frontend.Variable {
-
-	var out [16]frontend.Variable
-	for i := 0; i < 16; i++ {
-		out[i] = gcm.variableXor(a[i], b[i], 8)
-	}
-	return out
-}
-
-func (gcm *GCM) variableXor(a frontend.Variable, b frontend.Variable, size int) frontend.Variable {
-	bitsA := gcm.api.ToBinary(a, size)
-	bitsB := gcm.api.ToBinary(b, size)
-	x := make([]frontend.Variable, size)
-	for i := 0; i < size; i++ {
-		x[i] = gcm.api.Xor(bitsA[i], bitsB[i])
-	}
-	return gcm.api.FromBinary(x...)
-}
@@ -54,8 +54,11 @@ func NewAES128(api frontend.API) AES128 {
 
 // aes128 encrypt function
 func (aes *AES128) Encrypt(key [16]frontend.Variable, pt [16]frontend.Variable) [16]frontend.Variable {
-	// expand key
-	xk := aes.ExpandKey(key)
+	return aes.EncryptExpanded(aes.ExpandKey(key), pt)
+}
+
+// aes128 encryption with an expanded key
+func (aes *AES128) EncryptExpanded(xk [176]frontend.Variable, pt [16]frontend.Variable) [16]frontend.Variable {
 	var state [16]frontend.Variable
 	for i := 0; i < 16; i++ {
 		state[i] = aes.VariableXor(xk[i], pt[i], 8)
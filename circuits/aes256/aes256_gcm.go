@@ -0,0 +1,187 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package aes256
+
+import (
+	utils "circuits/utils"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// AES gcm testing
+type GCMWrapper struct {
+	Key            [32]frontend.Variable
+	PlainChunks    []frontend.Variable
+	Iv             [12]frontend.Variable `gnark:",public"`
+	ChunkIndex     frontend.Variable     `gnark:",public"`
+	CipherChunks   []frontend.Variable   `gnark:",public"`
+	SequenceNumber [8]frontend.Variable  `gnark:",public"`
+}
+
+// Define declares the circuit's constraints
+func (circuit *GCMWrapper) Define(api frontend.API) error {
+
+	aes := NewAES256(api)
+
+	gcm := NewGCM(api, &aes)
+
+	// verify aes gcm of chunks
+	gcm.Assert(circuit.Key, circuit.Iv, circuit.ChunkIndex, circuit.PlainChunks, circuit.CipherChunks, circuit.SequenceNumber)
+
+	return nil
+}
+
+// block cipher, the expanded key can be shared by every block encrypted under the key
+type AES interface {
+	Encrypt(key [32]frontend.Variable, pt [16]frontend.Variable) [16]frontend.Variable
+	ExpandKey(key [32]frontend.Variable) [240]frontend.Variable
+	EncryptExpanded(expandedKey [240]frontend.Variable, pt [16]frontend.Variable) [16]frontend.Variable
+}
+
+func NewGCM(api frontend.API, aes AES) GCM {
+	return GCM{api: api, aes: aes}
+}
+
+type GCM struct {
+	api frontend.API
+	aes AES
+}
+
+// aes gcm encryption, a trailing partial block is verified bytewise
+func (gcm *GCM) Assert(key [32]frontend.Variable, iv [12]frontend.Variable, chunkIndex frontend.Variable, plaintext, ciphertext []frontend.Variable, sequenceNumber [8]frontend.Variable) {
+	gcm.assert(gcm.aes.ExpandKey(key), iv, chunkIndex, plaintext, ciphertext, nil, sequenceNumber)
+}
+
+// aes gcm encryption under already expanded round keys, e.g. shared with the tag of the record
+func (gcm *GCM) AssertExpanded(expandedKey [240]frontend.Variable, iv [12]frontend.Variable, chunkIndex frontend.Variable, plaintext, ciphertext []frontend.Variable, sequenceNumber [8]frontend.Variable) {
+	gcm.assert(expandedKey, iv, chunkIndex, plaintext, ciphertext, nil, sequenceNumber)
+}
+
+// aes gcm encryption of the first length bytes, plaintext and ciphertext are sized for the maximum length
+// bytes from length on must be zero in both
+func (gcm *GCM) AssertMasked(key [32]frontend.Variable, iv [12]frontend.Variable, chunkIndex frontend.Variable, plaintext, ciphertext []frontend.Variable, length frontend.Variable, sequenceNumber [8]frontend.Variable) {
+	mask := utils.PrefixMask(gcm.api, length, len(plaintext))
+	gcm.assert(gcm.aes.ExpandKey(key), iv, chunkIndex, plaintext, ciphertext, mask, sequenceNumber)
+}
+
+// one key schedule for all counter blocks
+func (gcm *GCM) assert(expandedKey [240]frontend.Variable, iv [12]frontend.Variable, chunkIndex frontend.Variable, plaintext, ciphertext, mask []frontend.Variable, sequenceNumber [8]frontend.Variable) {
+
+	inputSize := len(plaintext)
+	numberBlocks := (inputSize + 15) / 16
+	var epoch int
+	for epoch = 0; epoch < numberBlocks; epoch++ {
+
+		idx := gcm.api.Add(chunkIndex, frontend.Variable(epoch))
+		eIndex := epoch * 16
+		blockSize := min(16, inputSize-eIndex)
+
+		var ptBlock [16]frontend.Variable
+		for j := 0; j < 16; j++ {
+			ptBlock[j] = 0
+		}
+		copy(ptBlock[:], plaintext[eIndex:eIndex+blockSize])
+
+		ivCounter := gcm.GetIVTLS13(iv, idx, sequenceNumber)
+		intermediate := gcm.aes.EncryptExpanded(expandedKey, ivCounter)
+		ct := gcm.Xor16(intermediate, ptBlock)
+
+		// check ciphertext to plaintext constraints
+		for i := 0; i < blockSize; i++ {
+			if mask == nil {
+				gcm.api.AssertIsEqual(ciphertext[eIndex+i], ct[i])
+				continue
+			}
+			// masked bytes are zero
+			m := mask[eIndex+i]
+			gcm.api.AssertIsEqual(ciphertext[eIndex+i], gcm.api.Mul(m, ct[i]))
+			gcm.api.AssertIsEqual(gcm.api.Mul(gcm.api.Sub(1, m), plaintext[eIndex+i]), 0)
+		}
+	}
+}
+
+// required for aes_gcm
+func (gcm *GCM) GetIV(nonce [12]frontend.Variable, ctr frontend.Variable) [16]frontend.Variable {
+
+	var out [16]frontend.Variable
+	var i int
+	for i = 0; i < len(nonce); i++ {
+		out[i] = nonce[i]
+	}
+	bits := gcm.api.ToBinary(ctr, 32)
+	remain := 12
+	for j := 3; j >= 0; j-- {
+		start := 8 * j
+		// little endian order chunk parsing from back to front
+		out[remain] = gcm.api.FromBinary(bits[start : start+8]...)
+		remain += 1
+	}
+	return out
+}
+
+func (gcm *GCM) GetIVTLS13(write_iv [12]frontend.Variable, ctr frontend.Variable, seq [8]frontend.Variable) [16]frontend.Variable {
+
+	var out [16]frontend.Variable
+	var i int
+
+	// left pad the big endian sequence number bytes to the iv length
+	var seqNumberPadded [12]frontend.Variable
+	for i := 0; i < 4; i++ {
+		seqNumberPadded[i] = 0
+	}
+	copy(seqNumberPadded[4:], seq[:])
+
+	var nonce [12]frontend.Variable
+	for i := 0; i < len(nonce); i++ {
+		nonce[i] = gcm.variableXor(seqNumberPadded[i], write_iv[i], 8)
+	}
+
+	for i = 0; i < len(nonce); i++ {
+		out[i] = nonce[i]
+	}
+
+	bits := gcm.api.ToBinary(ctr, 32)
+	remain := 12
+	for j := 3; j >= 0; j-- {
+		start := 8 * j
+		// little endian order chunk parsing from back to front
+		out[remain] = gcm.api.FromBinary(bits[start : start+8]...)
+		remain += 1
+	}
+
+	return out
+}
+
+// required for plaintext xor encrypted counter blocks
+func (gcm *GCM) Xor16(a [16]frontend.Variable, b [16]frontend.Variable) [16]frontend.Variable {
+
+	var out [16]frontend.Variable
+	for i := 0; i < 16; i++ {
+		out[i] = gcm.variableXor(a[i], b[i], 8)
+	}
+	return out
+}
+
+func (gcm *GCM) variableXor(a frontend.Variable, b frontend.Variable, size int) frontend.Variable {
+	bitsA := gcm.api.ToBinary(a, size)
+	bitsB := gcm.api.ToBinary(b, size)
+	x := make([]frontend.Variable, size)
+	for i := 0; i < size; i++ {
+		x[i] = gcm.api.Xor(bitsA[i], bitsB[i])
+	}
+	return gcm.api.FromBinary(x...)
+}
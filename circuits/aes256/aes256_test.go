@@ -0,0 +1,108 @@
+package aes256
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+
+	utils "circuits/utils"
+)
+
+const aes256Key = "1c9c7c260c39bcb8dcfa5fbc9330b9fa2872658573f95e87550cb26374e5f667"
+
+// Common setup function for both tests
+func setupAES256Wrapper() (AES256Wrapper, AES256Wrapper) {
+
+	key := utils.MustHex(aes256Key)
+	plain := utils.MustHex("00112233445566778899aabbccddeeff")
+
+	// calculate ciphertext ourselves
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		panic(err)
+	}
+	ciphertext := make([]byte, 16)
+	block.Encrypt(ciphertext, plain)
+
+	// witness values preparation
+	var assignment AES256Wrapper
+	for i := range assignment.Key {
+		assignment.Key[i] = key[i]
+	}
+	for i := range assignment.Plain {
+		assignment.Plain[i] = plain[i]
+		assignment.Cipher[i] = ciphertext[i]
+	}
+
+	var circuit AES256Wrapper
+
+	return circuit, assignment
+}
+
+// Test for Solving
+func TestAES256Solving(t *testing.T) {
+	assert := test.NewAssert(t)
+	circuit, assignment := setupAES256Wrapper()
+
+	// Solve the circuit and assert.
+	assert.SolvingSucceeded(&circuit, &assignment, test.WithBackends(backend.GROTH16))
+}
+
+// Test for Solving, second chunk of a record with sequence number 258
+func TestGCMSolving(t *testing.T) {
+
+	key := utils.MustHex(aes256Key)
+	iv := utils.MustHex("a54613bf2801a84ce693d0a0")
+	seq := []byte{0, 0, 0, 0, 0, 0, 1, 2}
+	plaintext := []byte(`{"data":{"currency":"EUR","amount":{"value":"38002.20"}}}`)
+
+	nonce := append([]byte{}, iv...)
+	for i := range seq {
+		nonce[4+i] ^= seq[i]
+	}
+	block, _ := aes.NewCipher(key)
+	aead, _ := cipher.NewGCM(block)
+	ciphertext := aead.Seal(nil, nonce, plaintext, nil)
+
+	// full blocks and a trailing partial block
+	for _, n := range []int{32, len(plaintext) - 16} {
+		assignment := GCMWrapper{
+			PlainChunks:  make([]frontend.Variable, n),
+			CipherChunks: make([]frontend.Variable, n),
+			ChunkIndex:   3,
+		}
+		for i := range assignment.Key {
+			assignment.Key[i] = key[i]
+		}
+		for i := range assignment.Iv {
+			assignment.Iv[i] = iv[i]
+		}
+		for i := range assignment.SequenceNumber {
+			assignment.SequenceNumber[i] = seq[i]
+		}
+		for i := range assignment.PlainChunks {
+			assignment.PlainChunks[i] = plaintext[16+i]
+			assignment.CipherChunks[i] = ciphertext[16+i]
+		}
+
+		circuit := GCMWrapper{
+			PlainChunks:  make([]frontend.Variable, n),
+			CipherChunks: make([]frontend.Variable, n),
+		}
+
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatal(n, err)
+		}
+
+		// last byte is verified
+		assignment.PlainChunks[n-1] = plaintext[16+n-1] ^ 1
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+			t.Fatal(n, "expected modified last byte to fail")
+		}
+	}
+}
@@ -18,6 +18,10 @@ package authtag
 
 import (
 	aes128 "circuits/aes128"
+	aes128lookup "circuits/aes128lookup"
+	aes256 "circuits/aes256"
+	utils "circuits/utils"
+	"fmt"
 
 	"github.com/consensys/gnark/frontend"
 )
@@ -34,11 +38,14 @@ type AuthTagWrapper struct {
 // Define declares the circuit's constraints
 func (circuit *AuthTagWrapper) Define(api frontend.API) error {
 
+	// private inputs are bytes
+	utils.AssertIsBytes(api, circuit.Key[:])
+
 	tag := NewTls13AuthTag(api)
 
 	// type conversion
 	tag.SetParams(
-		circuit.Key,
+		circuit.Key[:],
 		circuit.IvCounter,
 		circuit.Zeros,
 		circuit.ECB0,
@@ -46,25 +53,72 @@ func (circuit *AuthTagWrapper) Define(api frontend.API) error {
 	)
 
 	// verify tag
-	tag.Assert()
+	return tag.Assert()
+}
 
-	return nil
+// full authtag evaluation, ghash computed in-circuit
+type AuthTagGHashWrapper struct {
+	Key            [16]frontend.Variable
+	Iv             [12]frontend.Variable `gnark:",public"`
+	SequenceNumber [8]frontend.Variable  `gnark:",public"`
+	RecordHeader   [5]frontend.Variable  `gnark:",public"`
+	Ciphertext     []frontend.Variable   `gnark:",public"`
+	Tag            [16]frontend.Variable `gnark:",public"`
+}
+
+// Define declares the circuit's constraints
+func (circuit *AuthTagGHashWrapper) Define(api frontend.API) error {
+
+	// private inputs are bytes
+	utils.AssertIsBytes(api, circuit.Key[:])
+
+	tag := NewTls13AuthTag(api)
+
+	tag.SetRecordParams(
+		circuit.Key[:],
+		circuit.Iv,
+		circuit.SequenceNumber,
+		circuit.RecordHeader,
+		circuit.Ciphertext,
+		circuit.Tag,
+	)
+
+	// verify tag
+	return tag.AssertGHash()
 }
 
 type Tls13AuthTag struct {
 	api       frontend.API
-	Key       [16]frontend.Variable
+	aes       aes128.AES
+	Key       []frontend.Variable
 	IvCounter [16]frontend.Variable // `gnark:",public"`
 	Zeros     [16]frontend.Variable // `gnark:",public"`
 	ECB0      [16]frontend.Variable // `gnark:",public"`
 	ECBK      [16]frontend.Variable // `gnark:",public"`
+
+	// full tag params
+	Iv             [12]frontend.Variable // `gnark:",public"`
+	SequenceNumber [8]frontend.Variable  // `gnark:",public"`
+	RecordHeader   [5]frontend.Variable  // `gnark:",public"`
+	Ciphertext     []frontend.Variable   // `gnark:",public"`
+	Tag            [16]frontend.Variable // `gnark:",public"`
+
+	// round keys of Key, nil expands Key
+	expandedKey []frontend.Variable
 }
 
+// aes128 keys are encrypted with the lookup based aes128
 func NewTls13AuthTag(api frontend.API) Tls13AuthTag {
 	return Tls13AuthTag{api: api}
 }
 
-func (circuit *Tls13AuthTag) SetParams(key, ivCounter, zeros, ecb0, ecbk [16]frontend.Variable) {
+// aes128 keys are encrypted with the given implementation, nil selects the lookup based aes128
+func NewTls13AuthTagWithAES(api frontend.API, aes aes128.AES) Tls13AuthTag {
+	return Tls13AuthTag{api: api, aes: aes}
+}
+
+// the key length selects aes128 or aes256
+func (circuit *Tls13AuthTag) SetParams(key []frontend.Variable, ivCounter, zeros, ecb0, ecbk [16]frontend.Variable) {
 	circuit.Key = key
 	circuit.IvCounter = ivCounter
 	circuit.Zeros = zeros
@@ -72,14 +126,31 @@ func (circuit *Tls13AuthTag) SetParams(key, ivCounter, zeros, ecb0, ecbk [16]fro
 	circuit.ECBK = ecbk
 }
 
+// aes round keys of Key, e.g. shared with the record decryption, nil expands Key
+func (circuit *Tls13AuthTag) SetExpandedKey(expandedKey []frontend.Variable) {
+	circuit.expandedKey = expandedKey
+}
+
+func (circuit *Tls13AuthTag) SetRecordParams(key []frontend.Variable, iv [12]frontend.Variable, sequenceNumber [8]frontend.Variable, recordHeader [5]frontend.Variable, ciphertext []frontend.Variable, tag [16]frontend.Variable) {
+	circuit.Key = key
+	circuit.Iv = iv
+	circuit.SequenceNumber = sequenceNumber
+	circuit.RecordHeader = recordHeader
+	circuit.Ciphertext = ciphertext
+	circuit.Tag = tag
+}
+
 // Define declares the circuit's constraints
 func (circuit *Tls13AuthTag) Assert() error {
 
 	// aes circuit
-	aes := aes128.NewAES128(circuit.api)
+	encrypt, err := circuit.blockCipher()
+	if err != nil {
+		return err
+	}
 
 	// encrypt zeros
-	ecbk := aes.Encrypt(circuit.Key, circuit.Zeros)
+	ecbk := encrypt(circuit.Zeros)
 
 	// constraint check
 	for i := 0; i < len(circuit.ECBK); i++ {
@@ -87,7 +158,7 @@ func (circuit *Tls13AuthTag) Assert() error {
 	}
 
 	// encrypt iv||counter=0
-	ecb0 := aes.Encrypt(circuit.Key, circuit.IvCounter)
+	ecb0 := encrypt(circuit.IvCounter)
 
 	// constraints check
 	for i := 0; i < len(circuit.ECB0); i++ {
@@ -96,3 +167,89 @@ func (circuit *Tls13AuthTag) Assert() error {
 
 	return nil
 }
+
+// verifies the record tag, the record header is the aad of tls 1.3 records
+func (circuit *Tls13AuthTag) AssertGHash() error {
+
+	// aes circuit
+	encrypt, err := circuit.blockCipher()
+	if err != nil {
+		return err
+	}
+	// counter block and xor helpers only
+	gcm := aes128.NewGCM(circuit.api, nil)
+
+	// hash key H = E(K, 0^128)
+	var zeros [16]frontend.Variable
+	for i := range zeros {
+		zeros[i] = 0
+	}
+	h := encrypt(zeros)
+
+	// tag mask E(K, nonce||counter=1)
+	j0 := gcm.GetIVTLS13(circuit.Iv, 1, circuit.SequenceNumber)
+	mask := encrypt(j0)
+
+	// ghash over header and full ciphertext
+	ghash := NewGHash(circuit.api, h)
+	s := ghash.Sum(circuit.RecordHeader[:], circuit.Ciphertext)
+	tag := gcm.Xor16(mask, s)
+
+	// constraints check
+	for i := 0; i < len(circuit.Tag); i++ {
+		circuit.api.AssertIsEqual(circuit.Tag[i], tag[i])
+	}
+
+	return nil
+}
+
+// aes block encryption under the tag key, the key is expanded once for all blocks unless round keys are set
+func (circuit *Tls13AuthTag) blockCipher() (func([16]frontend.Variable) [16]frontend.Variable, error) {
+	switch len(circuit.Key) {
+	case 16:
+		var key [16]frontend.Variable
+		copy(key[:], circuit.Key)
+		if circuit.aes == nil {
+			aes := aes128lookup.NewAES128(circuit.api)
+			circuit.aes = &aes
+		}
+		var expandedKey [176]frontend.Variable
+		if err := circuit.roundKeys(expandedKey[:]); err != nil {
+			return nil, err
+		}
+		if circuit.expandedKey == nil {
+			expandedKey = circuit.aes.ExpandKey(key)
+		}
+		return func(pt [16]frontend.Variable) [16]frontend.Variable {
+			return circuit.aes.EncryptExpanded(expandedKey, pt)
+		}, nil
+	case 32:
+		var key [32]frontend.Variable
+		copy(key[:], circuit.Key)
+		aes := aes256.NewAES256(circuit.api)
+		var expandedKey [240]frontend.Variable
+		if err := circuit.roundKeys(expandedKey[:]); err != nil {
+			return nil, err
+		}
+		if circuit.expandedKey == nil {
+			expandedKey = aes.ExpandKey(key)
+		}
+		return func(pt [16]frontend.Variable) [16]frontend.Variable {
+			return aes.EncryptExpanded(expandedKey, pt)
+		}, nil
+	default:
+		return nil, fmt.Errorf("authtag: unsupported key length %d", len(circuit.Key))
+	}
+}
+
+// copies the set round keys into out, nothing is copied without round keys
+func (circuit *Tls13AuthTag) roundKeys(out []frontend.Variable) error {
+	if circuit.expandedKey == nil {
+		return nil
+	}
+	if len(circuit.expandedKey) != len(out) {
+		return fmt.Errorf("authtag: %d byte key requires %d round key bytes, got %d", len(circuit.Key), len(out), len(circuit.expandedKey))
+	}
+	copy(out, circuit.expandedKey)
+	return nil
+}
@@ -1,15 +1,20 @@
 package authtag
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"strings"
 	"testing"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/test"
 
+	aes128lookup "circuits/aes128lookup"
 	utils "circuits/utils"
 )
 
@@ -141,3 +146,167 @@ func TestKdcWrapperProving(t *testing.T) {
 	// Proof successfully generated
 	assert.ProverSucceeded(&circuit, &assignment)
 }
+
+// tls 1.3 application_data record sealed with crypto/cipher
+func setupAuthTagGHashWrapper(seq uint64) (AuthTagGHashWrapper, AuthTagGHashWrapper) {
+
+	key := utils.MustHex("2872658573f95e87550cb26374e5f667")
+	iv := utils.MustHex("a54613bf2801a84ce693d0a0")
+	inner := []byte(`{"amount":{"value":"38002.20"}}` + "\x17")
+
+	nonce := append([]byte{}, iv...)
+	var seqBytes [8]byte
+	binary.BigEndian.PutUint64(seqBytes[:], seq)
+	for i := 0; i < 8; i++ {
+		nonce[4+i] ^= seqBytes[i]
+	}
+	header := []byte{0x17, 0x03, 0x03, 0, byte(len(inner) + 16)}
+	block, _ := aes.NewCipher(key)
+	aead, _ := cipher.NewGCM(block)
+	sealed := aead.Seal(nil, nonce, inner, header)
+	ciphertext, tag := sealed[:len(inner)], sealed[len(inner):]
+
+	assignment := AuthTagGHashWrapper{Ciphertext: make([]frontend.Variable, len(ciphertext))}
+	for i := range assignment.Key {
+		assignment.Key[i] = key[i]
+	}
+	for i := range assignment.Iv {
+		assignment.Iv[i] = iv[i]
+	}
+	for i := range assignment.SequenceNumber {
+		assignment.SequenceNumber[i] = seqBytes[i]
+	}
+	for i := range assignment.RecordHeader {
+		assignment.RecordHeader[i] = header[i]
+	}
+	for i := range assignment.Ciphertext {
+		assignment.Ciphertext[i] = ciphertext[i]
+	}
+	for i := range assignment.Tag {
+		assignment.Tag[i] = tag[i]
+	}
+
+	circuit := AuthTagGHashWrapper{Ciphertext: make([]frontend.Variable, len(ciphertext))}
+
+	return circuit, assignment
+}
+
+// Test for Solving
+func TestAuthTagGHashSolving(t *testing.T) {
+	circuit, assignment := setupAuthTagGHashWrapper(258)
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// flipped tag bit
+	assignment.Tag[15] = assignment.Tag[15].(byte) ^ 1
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected invalid tag to fail")
+	}
+}
+
+// full authtag evaluation under an aes256 key
+type ghash256Circuit struct {
+	Key            [32]frontend.Variable
+	Iv             [12]frontend.Variable `gnark:",public"`
+	SequenceNumber [8]frontend.Variable  `gnark:",public"`
+	RecordHeader   [5]frontend.Variable  `gnark:",public"`
+	Ciphertext     []frontend.Variable   `gnark:",public"`
+	Tag            [16]frontend.Variable `gnark:",public"`
+}
+
+func (circuit *ghash256Circuit) Define(api frontend.API) error {
+	tag := NewTls13AuthTag(api)
+	tag.SetRecordParams(circuit.Key[:], circuit.Iv, circuit.SequenceNumber, circuit.RecordHeader, circuit.Ciphertext, circuit.Tag)
+	return tag.AssertGHash()
+}
+
+// Test for Solving
+func TestAuthTagGHash256Solving(t *testing.T) {
+
+	key := utils.MustHex("1c9c7c260c39bcb8dcfa5fbc9330b9fa2872658573f95e87550cb26374e5f667")
+	iv := utils.MustHex("a54613bf2801a84ce693d0a0")
+	inner := []byte(`{"amount":{"value":"38002.20"}}` + "\x17")
+	seq := []byte{0, 0, 0, 0, 0, 0, 0, 1}
+
+	nonce := append([]byte{}, iv...)
+	for i := range seq {
+		nonce[4+i] ^= seq[i]
+	}
+	header := []byte{0x17, 0x03, 0x03, 0, byte(len(inner) + 16)}
+	block, _ := aes.NewCipher(key)
+	aead, _ := cipher.NewGCM(block)
+	sealed := aead.Seal(nil, nonce, inner, header)
+	ciphertext, tag := sealed[:len(inner)], sealed[len(inner):]
+
+	assignment := ghash256Circuit{Ciphertext: make([]frontend.Variable, len(ciphertext))}
+	for i := range assignment.Key {
+		assignment.Key[i] = key[i]
+	}
+	for i := range assignment.Iv {
+		assignment.Iv[i] = iv[i]
+	}
+	for i := range assignment.SequenceNumber {
+		assignment.SequenceNumber[i] = seq[i]
+	}
+	for i := range assignment.RecordHeader {
+		assignment.RecordHeader[i] = header[i]
+	}
+	for i := range assignment.Ciphertext {
+		assignment.Ciphertext[i] = ciphertext[i]
+	}
+	for i := range assignment.Tag {
+		assignment.Tag[i] = tag[i]
+	}
+
+	circuit := ghash256Circuit{Ciphertext: make([]frontend.Variable, len(ciphertext))}
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Test for out of range private inputs
+func TestAuthTagRejectsNonBytes(t *testing.T) {
+	circuit, assignment := setupAuthTagWrapper()
+	assignment.Key[0] = 256
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected out of range key to fail")
+	}
+}
+
+// tag with round keys expanded outside the gadget, e.g. by the oracle
+type expandedTagWrapper struct {
+	AuthTagWrapper
+	ExpansionKey [16]frontend.Variable
+}
+
+func (circuit *expandedTagWrapper) Define(api frontend.API) error {
+
+	lookup := aes128lookup.NewAES128(api)
+	expandedKey := lookup.ExpandKey(circuit.ExpansionKey)
+
+	tag := NewTls13AuthTagWithAES(api, &lookup)
+	tag.SetParams(circuit.Key[:], circuit.IvCounter, circuit.Zeros, circuit.ECB0, circuit.ECBK)
+	tag.SetExpandedKey(expandedKey[:])
+
+	return tag.Assert()
+}
+
+func TestAuthTagExpandedKey(t *testing.T) {
+	_, inner := setupAuthTagWrapper()
+
+	var circuit expandedTagWrapper
+	assignment := expandedTagWrapper{AuthTagWrapper: inner, ExpansionKey: inner.Key}
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// round keys of another key
+	assignment.ExpansionKey[0] = (inner.Key[0].(int) + 1) % 256
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected round keys of another key to fail")
+	}
+}
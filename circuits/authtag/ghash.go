@@ -0,0 +1,128 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authtag
+
+import (
+	"github.com/consensys/gnark/frontend"
+)
+
+// ghash as specified in nist sp 800-38d, bits are kept in gcm order (bit 0 is the msb of byte 0)
+type GHash struct {
+	api frontend.API
+	// hx[i] = H * x^i, precomputed once per hash key
+	hx [128][128]frontend.Variable
+	y  [128]frontend.Variable
+}
+
+func NewGHash(api frontend.API, h [16]frontend.Variable) GHash {
+
+	g := GHash{api: api}
+
+	g.hx[0] = g.toBits(h)
+	for i := 1; i < 128; i++ {
+		// V >> 1, xor R = 11100001 || 0^120 if the dropped bit is set
+		prev := g.hx[i-1]
+		carry := prev[127]
+		g.hx[i][0] = carry
+		for j := 1; j < 128; j++ {
+			g.hx[i][j] = prev[j-1]
+		}
+		for _, j := range []int{1, 2, 7} {
+			g.hx[i][j] = api.Xor(g.hx[i][j], carry)
+		}
+	}
+
+	for i := range g.y {
+		g.y[i] = 0
+	}
+	return g
+}
+
+// Y = (Y xor X) * H
+func (g *GHash) Update(block [16]frontend.Variable) {
+
+	x := g.toBits(block)
+	for i := range x {
+		x[i] = g.api.Xor(x[i], g.y[i])
+	}
+
+	// every product bit is the parity of sum_i x_i * (H * x^i)_k
+	for k := 0; k < 128; k++ {
+		var sum frontend.Variable = 0
+		for i := 0; i < 128; i++ {
+			sum = g.api.Add(sum, g.api.Mul(x[i], g.hx[i][k]))
+		}
+		g.y[k] = g.api.ToBinary(sum, 8)[0]
+	}
+}
+
+// GHASH(H, A, C) over the zero padded aad and ciphertext followed by the length block
+func (g *GHash) Sum(aad, ciphertext []frontend.Variable) [16]frontend.Variable {
+
+	g.write(aad)
+	g.write(ciphertext)
+
+	var lengths [16]frontend.Variable
+	aadBits := uint64(len(aad)) * 8
+	ctBits := uint64(len(ciphertext)) * 8
+	for i := 0; i < 8; i++ {
+		lengths[i] = (aadBits >> (56 - 8*i)) & 0xff
+		lengths[8+i] = (ctBits >> (56 - 8*i)) & 0xff
+	}
+	g.Update(lengths)
+
+	return g.toBytes(g.y)
+}
+
+// hashes data block by block, the last partial block is padded with zeros
+func (g *GHash) write(data []frontend.Variable) {
+	for start := 0; start < len(data); start += 16 {
+		var block [16]frontend.Variable
+		for i := range block {
+			if start+i < len(data) {
+				block[i] = data[start+i]
+			} else {
+				block[i] = 0
+			}
+		}
+		g.Update(block)
+	}
+}
+
+func (g *GHash) toBits(block [16]frontend.Variable) [128]frontend.Variable {
+	var out [128]frontend.Variable
+	for b := 0; b < 16; b++ {
+		// ToBinary is little endian
+		bits := g.api.ToBinary(block[b], 8)
+		for p := 0; p < 8; p++ {
+			out[8*b+p] = bits[7-p]
+		}
+	}
+	return out
+}
+
+func (g *GHash) toBytes(bits [128]frontend.Variable) [16]frontend.Variable {
+	var out [16]frontend.Variable
+	for b := 0; b < 16; b++ {
+		le := make([]frontend.Variable, 8)
+		for p := 0; p < 8; p++ {
+			le[7-p] = bits[8*b+p]
+		}
+		out[b] = g.api.FromBinary(le...)
+	}
+	return out
+}
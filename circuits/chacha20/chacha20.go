@@ -0,0 +1,223 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chacha20
+
+import (
+	utils "circuits/utils"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+)
+
+// chacha20 testing
+type ChaCha20Wrapper struct {
+	Key            [32]frontend.Variable
+	PlainChunks    []frontend.Variable
+	Iv             [12]frontend.Variable `gnark:",public"`
+	Counter        frontend.Variable     `gnark:",public"`
+	CipherChunks   []frontend.Variable   `gnark:",public"`
+	SequenceNumber [8]frontend.Variable  `gnark:",public"`
+}
+
+// Define declares the circuit's constraints
+func (circuit *ChaCha20Wrapper) Define(api frontend.API) error {
+
+	chacha := NewChaCha20(api)
+
+	// verify chacha20 of chunks
+	chacha.Assert(circuit.Key, circuit.Iv, circuit.Counter, circuit.PlainChunks, circuit.CipherChunks, circuit.SequenceNumber)
+
+	return nil
+}
+
+// "expand 32-byte k"
+var sigma = [4]uint32{0x61707865, 0x3320646e, 0x79622d32, 0x6b206574}
+
+// 32-bit word as little endian bits
+type word [32]frontend.Variable
+
+type ChaCha20 struct {
+	api frontend.API
+}
+
+func NewChaCha20(api frontend.API) ChaCha20 {
+	return ChaCha20{api: api}
+}
+
+// chacha20 decryption of 64 byte blocks, rfc8439 section 2.4
+// the first chunk is encrypted with the block counter, the last chunk may be partial
+func (c *ChaCha20) Assert(key [32]frontend.Variable, iv [12]frontend.Variable, counter frontend.Variable, plaintext, ciphertext []frontend.Variable, sequenceNumber [8]frontend.Variable) {
+	c.assert(key, iv, counter, plaintext, ciphertext, nil, sequenceNumber)
+}
+
+// chacha20 decryption of the first length bytes, bytes from length on must be zero in both
+func (c *ChaCha20) AssertMasked(key [32]frontend.Variable, iv [12]frontend.Variable, counter frontend.Variable, plaintext, ciphertext []frontend.Variable, length frontend.Variable, sequenceNumber [8]frontend.Variable) {
+	mask := utils.PrefixMask(c.api, length, len(plaintext))
+	c.assert(key, iv, counter, plaintext, ciphertext, mask, sequenceNumber)
+}
+
+func (c *ChaCha20) assert(key [32]frontend.Variable, iv [12]frontend.Variable, counter frontend.Variable, plaintext, ciphertext, mask []frontend.Variable, sequenceNumber [8]frontend.Variable) {
+
+	nonce := c.NonceTLS13(iv, sequenceNumber)
+
+	for start := 0; start < len(plaintext); start += 64 {
+		idx := c.api.Add(counter, start/64)
+		keystream := c.blockBits(key, nonce, idx)
+
+		for i := start; i < len(plaintext) && i < start+64; i++ {
+			ptBits := bits.ToBinary(c.api, plaintext[i], bits.WithNbDigits(8))
+			ctBits := make([]frontend.Variable, 8)
+			for j := 0; j < 8; j++ {
+				ctBits[j] = c.api.Xor(ptBits[j], keystream[8*(i-start)+j])
+			}
+			ct := bits.FromBinary(c.api, ctBits, bits.WithUnconstrainedInputs())
+			if mask == nil {
+				c.api.AssertIsEqual(ciphertext[i], ct)
+				continue
+			}
+			// masked bytes are zero
+			c.api.AssertIsEqual(ciphertext[i], c.api.Mul(mask[i], ct))
+			c.api.AssertIsEqual(c.api.Mul(c.api.Sub(1, mask[i]), plaintext[i]), 0)
+		}
+	}
+}
+
+// nonce = iv xor padded sequence number, rfc8446 section 5.3
+func (c *ChaCha20) NonceTLS13(iv [12]frontend.Variable, seq [8]frontend.Variable) [12]frontend.Variable {
+	var nonce [12]frontend.Variable
+	for i := 0; i < 4; i++ {
+		nonce[i] = iv[i]
+	}
+	for i := 0; i < 8; i++ {
+		ivBits := bits.ToBinary(c.api, iv[4+i], bits.WithNbDigits(8))
+		seqBits := bits.ToBinary(c.api, seq[i], bits.WithNbDigits(8))
+		x := make([]frontend.Variable, 8)
+		for j := range x {
+			x[j] = c.api.Xor(ivBits[j], seqBits[j])
+		}
+		nonce[4+i] = bits.FromBinary(c.api, x, bits.WithUnconstrainedInputs())
+	}
+	return nonce
+}
+
+// keystream block for the given block counter
+func (c *ChaCha20) Block(key [32]frontend.Variable, nonce [12]frontend.Variable, counter frontend.Variable) [64]frontend.Variable {
+	ks := c.blockBits(key, nonce, counter)
+	var out [64]frontend.Variable
+	for i := range out {
+		out[i] = bits.FromBinary(c.api, ks[8*i:8*i+8], bits.WithUnconstrainedInputs())
+	}
+	return out
+}
+
+// chacha20 block function, rfc8439 section 2.3, returns the serialized block as little endian bits
+func (c *ChaCha20) blockBits(key [32]frontend.Variable, nonce [12]frontend.Variable, counter frontend.Variable) []frontend.Variable {
+
+	var state [16]word
+	for i := 0; i < 4; i++ {
+		state[i] = constWord(sigma[i])
+	}
+	for i := 0; i < 8; i++ {
+		state[4+i] = c.wordFromBytes(key[4*i : 4*i+4])
+	}
+	state[12] = c.asWord(counter)
+	for i := 0; i < 3; i++ {
+		state[13+i] = c.wordFromBytes(nonce[4*i : 4*i+4])
+	}
+
+	x := state
+	for i := 0; i < 10; i++ {
+		// column rounds
+		c.quarterRound(&x, 0, 4, 8, 12)
+		c.quarterRound(&x, 1, 5, 9, 13)
+		c.quarterRound(&x, 2, 6, 10, 14)
+		c.quarterRound(&x, 3, 7, 11, 15)
+		// diagonal rounds
+		c.quarterRound(&x, 0, 5, 10, 15)
+		c.quarterRound(&x, 1, 6, 11, 12)
+		c.quarterRound(&x, 2, 7, 8, 13)
+		c.quarterRound(&x, 3, 4, 9, 14)
+	}
+
+	out := make([]frontend.Variable, 0, 512)
+	for i := range x {
+		w := c.add(x[i], state[i])
+		out = append(out, w[:]...)
+	}
+	return out
+}
+
+func (c *ChaCha20) quarterRound(x *[16]word, a, b, cc, d int) {
+	x[a] = c.add(x[a], x[b])
+	x[d] = lrot(c.xor(x[d], x[a]), 16)
+	x[cc] = c.add(x[cc], x[d])
+	x[b] = lrot(c.xor(x[b], x[cc]), 12)
+	x[a] = c.add(x[a], x[b])
+	x[d] = lrot(c.xor(x[d], x[a]), 8)
+	x[cc] = c.add(x[cc], x[d])
+	x[b] = lrot(c.xor(x[b], x[cc]), 7)
+}
+
+func constWord(a uint32) word {
+	var res word
+	for i := 0; i < 32; i++ {
+		res[i] = (a >> i) & 1
+	}
+	return res
+}
+
+func (c *ChaCha20) asWord(in frontend.Variable) word {
+	var res word
+	copy(res[:], bits.ToBinary(c.api, in, bits.WithNbDigits(32)))
+	return res
+}
+
+// little endian word of four bytes
+func (c *ChaCha20) wordFromBytes(in []frontend.Variable) word {
+	var res word
+	for i := 0; i < 4; i++ {
+		copy(res[8*i:], bits.ToBinary(c.api, in[i], bits.WithNbDigits(8)))
+	}
+	return res
+}
+
+func (c *ChaCha20) fromWord(in word) frontend.Variable {
+	return bits.FromBinary(c.api, in[:], bits.WithUnconstrainedInputs())
+}
+
+func (c *ChaCha20) add(a, b word) word {
+	sum := c.api.Add(c.fromWord(a), c.fromWord(b))
+	var res word
+	copy(res[:], bits.ToBinary(c.api, sum, bits.WithNbDigits(33)))
+	return res
+}
+
+func (c *ChaCha20) xor(a, b word) word {
+	var res word
+	for i := range res {
+		res[i] = c.api.Xor(a[i], b[i])
+	}
+	return res
+}
+
+func lrot(in word, shift int) word {
+	var res word
+	for i := range res {
+		res[i] = in[(i-shift+32)%32]
+	}
+	return res
+}
@@ -0,0 +1,114 @@
+package chacha20
+
+import (
+	"encoding/binary"
+	"testing"
+
+	utils "circuits/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+const recordBody = `{"data":{"currency":"EUR","amount":{"value":"38002.20"}},"status":"ok","padding":"xxxxxxxxxxxxxxxxxxxx"}`
+
+// tls 1.3 application_data record sealed with x/crypto
+func sealRecord(seq uint64) (key, iv, seqBytes, header, ciphertext, tag []byte) {
+
+	key = utils.MustHex("1c9c7c260c39bcb8dcfa5fbc9330b9fa2872658573f95e87550cb26374e5f667")
+	iv = utils.MustHex("a54613bf2801a84ce693d0a0")
+	inner := []byte(recordBody + "\x17")
+
+	nonce := append([]byte{}, iv...)
+	seqBytes = binary.BigEndian.AppendUint64(nil, seq)
+	for i := 0; i < 8; i++ {
+		nonce[4+i] ^= seqBytes[i]
+	}
+	header = []byte{0x17, 0x03, 0x03, 0, byte(len(inner) + 16)}
+
+	aead, _ := chacha20poly1305.New(key)
+	sealed := aead.Seal(nil, nonce, inner, header)
+	return key, iv, seqBytes, header, sealed[:len(inner)], sealed[len(inner):]
+}
+
+// Test for Solving, second keystream block
+func TestChaCha20Solving(t *testing.T) {
+
+	key, iv, seq, _, ciphertext, _ := sealRecord(258)
+	plaintext := []byte(recordBody)[64:]
+	ciphertext = ciphertext[64 : 64+len(plaintext)]
+
+	assignment := ChaCha20Wrapper{
+		PlainChunks:  make([]frontend.Variable, len(plaintext)),
+		CipherChunks: make([]frontend.Variable, len(ciphertext)),
+		Counter:      2,
+	}
+	for i := range assignment.Key {
+		assignment.Key[i] = key[i]
+	}
+	for i := range assignment.Iv {
+		assignment.Iv[i] = iv[i]
+	}
+	for i := range assignment.SequenceNumber {
+		assignment.SequenceNumber[i] = seq[i]
+	}
+	for i := range plaintext {
+		assignment.PlainChunks[i] = plaintext[i]
+		assignment.CipherChunks[i] = ciphertext[i]
+	}
+
+	circuit := ChaCha20Wrapper{
+		PlainChunks:  make([]frontend.Variable, len(plaintext)),
+		CipherChunks: make([]frontend.Variable, len(ciphertext)),
+	}
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// wrong block counter
+	assignment.Counter = 1
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected wrong counter to fail")
+	}
+}
+
+// Test for Solving
+func TestPoly1305Solving(t *testing.T) {
+
+	key, iv, seq, header, ciphertext, tag := sealRecord(258)
+
+	assignment := Poly1305Wrapper{Ciphertext: make([]frontend.Variable, len(ciphertext))}
+	for i := range assignment.Key {
+		assignment.Key[i] = key[i]
+	}
+	for i := range assignment.Iv {
+		assignment.Iv[i] = iv[i]
+	}
+	for i := range assignment.SequenceNumber {
+		assignment.SequenceNumber[i] = seq[i]
+	}
+	for i := range assignment.RecordHeader {
+		assignment.RecordHeader[i] = header[i]
+	}
+	for i := range assignment.Ciphertext {
+		assignment.Ciphertext[i] = ciphertext[i]
+	}
+	for i := range assignment.Tag {
+		assignment.Tag[i] = tag[i]
+	}
+
+	circuit := Poly1305Wrapper{Ciphertext: make([]frontend.Variable, len(ciphertext))}
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// flipped tag bit
+	assignment.Tag[0] = tag[0] ^ 1
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected invalid tag to fail")
+	}
+}
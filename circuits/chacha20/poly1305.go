@@ -0,0 +1,152 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package chacha20
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/emulated"
+)
+
+// record tag testing
+type Poly1305Wrapper struct {
+	Key            [32]frontend.Variable
+	Iv             [12]frontend.Variable `gnark:",public"`
+	SequenceNumber [8]frontend.Variable  `gnark:",public"`
+	RecordHeader   [5]frontend.Variable  `gnark:",public"`
+	Ciphertext     []frontend.Variable   `gnark:",public"`
+	Tag            [16]frontend.Variable `gnark:",public"`
+}
+
+// Define declares the circuit's constraints
+func (circuit *Poly1305Wrapper) Define(api frontend.API) error {
+
+	chacha := NewChaCha20(api)
+
+	// verify tag
+	return chacha.AssertTag(circuit.Key, circuit.Iv, circuit.SequenceNumber, circuit.RecordHeader, circuit.Ciphertext, circuit.Tag)
+}
+
+// emulated field modulo 2^130-5
+type p1305 struct{}
+
+func (p1305) NbLimbs() uint     { return 2 }
+func (p1305) BitsPerLimb() uint { return 65 }
+func (p1305) IsPrime() bool     { return true }
+func (p1305) Modulus() *big.Int {
+	p := new(big.Int).Lsh(big.NewInt(1), 130)
+	return p.Sub(p, big.NewInt(5))
+}
+
+// verifies the tls 1.3 record tag, rfc8439 section 2.8, the record header is the aad
+func (c *ChaCha20) AssertTag(key [32]frontend.Variable, iv [12]frontend.Variable, sequenceNumber [8]frontend.Variable, recordHeader [5]frontend.Variable, ciphertext []frontend.Variable, tag [16]frontend.Variable) error {
+
+	// one-time key is the first half of block 0
+	nonce := c.NonceTLS13(iv, sequenceNumber)
+	block := c.Block(key, nonce, 0)
+	var otk [32]frontend.Variable
+	copy(otk[:], block[:32])
+
+	// aad || pad16 || ciphertext || pad16 || le64(len(aad)) || le64(len(ciphertext))
+	msg := pad16(recordHeader[:])
+	msg = append(msg, pad16(ciphertext)...)
+	for _, l := range []int{len(recordHeader), len(ciphertext)} {
+		for i := 0; i < 8; i++ {
+			msg = append(msg, (l>>(8*i))&0xff)
+		}
+	}
+
+	mac, err := c.Poly1305(otk, msg)
+	if err != nil {
+		return err
+	}
+
+	// constraints check
+	for i := range tag {
+		c.api.AssertIsEqual(tag[i], mac[i])
+	}
+	return nil
+}
+
+// poly1305 mac of msg under the one-time key, rfc8439 section 2.5
+func (c *ChaCha20) Poly1305(key [32]frontend.Variable, msg []frontend.Variable) ([16]frontend.Variable, error) {
+
+	var tag [16]frontend.Variable
+
+	field, err := emulated.NewField[p1305](c.api)
+	if err != nil {
+		return tag, err
+	}
+
+	// clamp r, cleared bits are dropped from the bit decomposition
+	rBits := make([]frontend.Variable, 0, 128)
+	for i := 0; i < 16; i++ {
+		b := bits.ToBinary(c.api, key[i], bits.WithNbDigits(8))
+		switch i {
+		case 3, 7, 11, 15:
+			b = append(b[:4:4], 0, 0, 0, 0)
+		case 4, 8, 12:
+			b = append([]frontend.Variable{0, 0}, b[2:]...)
+		}
+		rBits = append(rBits, b...)
+	}
+	r := field.FromBits(rBits...)
+
+	// acc = (acc + chunk || 0x01) * r
+	acc := field.Zero()
+	for start := 0; start < len(msg); start += 16 {
+		end := min(start+16, len(msg))
+		chunkBits := make([]frontend.Variable, 0, 129)
+		for i := start; i < end; i++ {
+			chunkBits = append(chunkBits, bits.ToBinary(c.api, msg[i], bits.WithNbDigits(8))...)
+		}
+		chunkBits = append(chunkBits, 1)
+		acc = field.Mul(field.Add(acc, field.FromBits(chunkBits...)), r)
+	}
+
+	// canonical accumulator
+	acc = field.Reduce(acc)
+	field.AssertIsInRange(acc)
+	accBits := field.ToBits(acc)[:130]
+
+	// tag = (acc + s) mod 2^128
+	sBits := make([]frontend.Variable, 0, 128)
+	for i := 16; i < 32; i++ {
+		sBits = append(sBits, bits.ToBinary(c.api, key[i], bits.WithNbDigits(8))...)
+	}
+	sum := c.api.Add(
+		bits.FromBinary(c.api, accBits, bits.WithUnconstrainedInputs()),
+		bits.FromBinary(c.api, sBits, bits.WithUnconstrainedInputs()),
+	)
+	sumBits := bits.ToBinary(c.api, sum, bits.WithNbDigits(131))
+	for i := range tag {
+		tag[i] = bits.FromBinary(c.api, sumBits[8*i:8*i+8], bits.WithUnconstrainedInputs())
+	}
+
+	return tag, nil
+}
+
+// zero pads to a multiple of 16 bytes
+func pad16(in []frontend.Variable) []frontend.Variable {
+	out := append([]frontend.Variable{}, in...)
+	for len(out)%16 != 0 {
+		out = append(out, 0)
+	}
+	return out
+}
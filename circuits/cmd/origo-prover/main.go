@@ -0,0 +1,86 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// origo-prover drives the Tls13OracleWrapper circuit end to end.
+//
+// usage:
+//
+//	origo-prover compile -backend groth16 -params params.json -out build
+//	origo-prover setup   -backend groth16 -out build
+//	origo-prover setup   -backend plonk -srs srs.kzg -out build
+//	origo-prover prove   -backend groth16 -params params.json -threshold 38001 -out build
+//	origo-prover verify  -backend groth16 -out build
+//	origo-prover profile -backend groth16 -params params.json -out build
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <compile|setup|prove|verify|profile> [flags]\n", os.Args[0])
+	os.Exit(2)
+}
+
+func main() {
+
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	cmd := os.Args[1]
+	fs := flag.NewFlagSet(cmd, flag.ExitOnError)
+	backend := fs.String("backend", "groth16", "proof system backend: groth16, plonk or plonkFRI")
+	params := fs.String("params", "", "path to the json parameter file")
+	threshold := fs.Int("threshold", 0, "public threshold the extracted value is compared against")
+	srs := fs.String("srs", "", "path to the kzg srs of a plonk setup, e.g. converted from a powers of tau ceremony")
+	out := fs.String("out", ".", "directory of the constraint system, keys, proof and public witness")
+	debug := fs.Bool("debug", false, "enable debug logging")
+	fs.Parse(os.Args[2:])
+
+	zerolog.SetGlobalLevel(zerolog.InfoLevel)
+	if *debug {
+		zerolog.SetGlobalLevel(zerolog.DebugLevel)
+	}
+
+	p := newProver(*backend, *out, *srs)
+
+	var err error
+	switch cmd {
+	case "compile":
+		err = p.compile(*params)
+	case "setup":
+		err = p.setup()
+	case "prove":
+		err = p.prove(*params, *threshold)
+	case "verify":
+		err = p.verify()
+	case "profile":
+		err = p.profile(*params)
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatal().Err(err).Str("backend", *backend).Msg(cmd)
+	}
+}
@@ -0,0 +1,308 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	origo "circuits/origo"
+	utils "circuits/utils"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+)
+
+// file names inside the output directory
+const (
+	proofFile   = "proof"
+	witnessFile = "public.wtns"
+	profileFile = "tls13oracle.pprof"
+)
+
+// key store entry of the oracle circuit
+const circuitName = "tls13oracle"
+
+// plonkFRI keys and proofs have no serialization in gnark
+var errPlonkFRI = errors.New("plonkFRI keys and proofs cannot be serialized, use prove to run setup, prove and verify in one step")
+
+var curveID = ecc.BN254
+
+// returns circuit definition and assignment for a parameter file and threshold
+type loader func(params string, threshold int) (frontend.Circuit, frontend.Circuit, error)
+
+type prover struct {
+	backend string
+	dir     string
+	srs     string // path of the kzg srs, plonk only
+	ks      utils.KeyStore
+
+	// key store entry and parameter loader of the circuit
+	name string
+	load loader
+}
+
+// prover of the oracle circuit
+func newProver(backend, dir, srs string) prover {
+	return newCircuitProver(backend, dir, srs, circuitName, func(params string, threshold int) (frontend.Circuit, frontend.Circuit, error) {
+		circuit, assignment, err := loadOracle(params, threshold)
+		return &circuit, &assignment, err
+	})
+}
+
+func newCircuitProver(backend, dir, srs, name string, load loader) prover {
+	return prover{backend: backend, dir: dir, srs: srs, ks: utils.NewKeyStore(dir, backend, curveID), name: name, load: load}
+}
+
+func (p *prover) path(name string) string {
+	return filepath.Join(p.dir, name)
+}
+
+// loads the json parameters and returns circuit and assignment of the oracle circuit
+func loadOracle(params string, threshold int) (origo.Tls13OracleWrapper, origo.Tls13OracleWrapper, error) {
+	if params == "" {
+		return origo.Tls13OracleWrapper{}, origo.Tls13OracleWrapper{}, errors.New("missing -params")
+	}
+	raw, err := os.ReadFile(params)
+	if err != nil {
+		return origo.Tls13OracleWrapper{}, origo.Tls13OracleWrapper{}, err
+	}
+	data, err := origo.ParseFinalParams(raw)
+	if err != nil {
+		return origo.Tls13OracleWrapper{}, origo.Tls13OracleWrapper{}, err
+	}
+	return origo.NewTls13OracleWrapperFromParams(data, threshold)
+}
+
+// compiles the circuit for the given parameters
+func (p *prover) compileCircuit(circuit frontend.Circuit) (constraint.ConstraintSystem, error) {
+
+	builder, err := utils.BackendBuilder(p.backend)
+	if err != nil {
+		return nil, err
+	}
+
+	ccs, err := frontend.Compile(curveID.ScalarField(), builder, circuit)
+	if err != nil {
+		return nil, err
+	}
+	log.Info().Int("constraints", ccs.GetNbConstraints()).Msg("compiled constraint system")
+
+	return ccs, nil
+}
+
+// compiles the circuit and writes the constraint system to disk
+func (p *prover) compile(params string) error {
+
+	circuit, _, err := p.load(params, 0)
+	if err != nil {
+		return err
+	}
+
+	ccs, err := p.compileCircuit(circuit)
+	if err != nil {
+		return err
+	}
+
+	fingerprint, err := p.ks.SaveCCS(p.name, ccs)
+	if err != nil {
+		return err
+	}
+	log.Info().Str("fingerprint", fingerprint).Msg("stored constraint system")
+
+	return nil
+}
+
+// compiles the oracle circuit, writes the pprof profile of its constraints and prints the constraints per stage
+func (p *prover) profile(params string) error {
+
+	circuit, _, err := loadOracle(params, 0)
+	if err != nil {
+		return err
+	}
+	circuit.Stages = utils.NewStages()
+
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		return err
+	}
+	breakdown, err := utils.ProfileWithBackend(p.backend, &circuit, circuit.Stages, p.path(profileFile))
+	if err != nil {
+		return err
+	}
+	log.Info().Str("path", p.path(profileFile)).Msg("stored constraint profile")
+	fmt.Print(breakdown)
+
+	return nil
+}
+
+// runs the setup on the stored constraint system and writes the keys to disk
+func (p *prover) setup() error {
+
+	if p.backend == "plonkFRI" {
+		return errPlonkFRI
+	}
+
+	ccs, err := p.ks.LoadCCS(p.name)
+	if err != nil {
+		return err
+	}
+
+	// plonk keys are only as sound as the srs, there is no fallback to a generated one
+	var srs kzg.SRS
+	if p.backend == "plonk" {
+		if p.srs == "" {
+			return fmt.Errorf("%w, pass -srs", utils.ErrMissingSRS)
+		}
+		srs, err = utils.LoadSRS(p.srs, curveID)
+		if err != nil {
+			return err
+		}
+	}
+
+	keys, err := utils.SetupWithBackend(p.backend, ccs, srs)
+	if err != nil {
+		return err
+	}
+	log.Info().Str("fingerprint", keys.Fingerprint).Msg("stored keys")
+
+	return p.ks.Save(p.name, keys)
+}
+
+// proves the assignment against the stored constraint system and proving key
+func (p *prover) prove(params string, threshold int) error {
+
+	circuit, assignment, err := p.load(params, threshold)
+	if err != nil {
+		return err
+	}
+
+	// recompile so that keys of a different circuit shape are detected
+	ccs, err := p.compileCircuit(circuit)
+	if err != nil {
+		return err
+	}
+
+	fullWitness, err := frontend.NewWitness(assignment, curveID.ScalarField())
+	if err != nil {
+		return err
+	}
+	publicWitness, err := fullWitness.Public()
+	if err != nil {
+		return err
+	}
+
+	if p.backend == "plonkFRI" {
+		// transparent setup, keys are derived from the constraint system
+		pk, vk, err := utils.BackendSetup(p.backend, ccs, nil)
+		if err != nil {
+			return err
+		}
+		proof, err := utils.BackendProve(p.backend, ccs, pk, fullWitness)
+		if err != nil {
+			return err
+		}
+		if err := utils.BackendVerify(p.backend, proof, vk, publicWitness); err != nil {
+			return err
+		}
+		log.Warn().Msg("plonkFRI proof verified in-process, proof is not written to disk")
+		return p.write(witnessFile, publicWitness)
+	}
+
+	keys, err := p.ks.Load(p.name, ccs)
+	if err != nil {
+		return err
+	}
+	proof, err := utils.BackendProve(p.backend, ccs, keys.PK, fullWitness)
+	if err != nil {
+		return err
+	}
+
+	if err := p.write(proofFile, proof.(io.WriterTo)); err != nil {
+		return err
+	}
+	return p.write(witnessFile, publicWitness)
+}
+
+// verifies the stored proof against the stored verifying key and public witness
+func (p *prover) verify() error {
+
+	publicWitness, err := witness.New(curveID.ScalarField())
+	if err != nil {
+		return err
+	}
+	if err := p.read(witnessFile, publicWitness); err != nil {
+		return err
+	}
+
+	if p.backend == "plonkFRI" {
+		return errPlonkFRI
+	}
+
+	keys, err := p.ks.Load(p.name, nil)
+	if err != nil {
+		return err
+	}
+
+	_, _, proof, err := utils.BackendKeys(p.backend, curveID)
+	if err != nil {
+		return err
+	}
+	if err := p.read(proofFile, proof); err != nil {
+		return err
+	}
+	if err := utils.BackendVerify(p.backend, proof, keys.VK, publicWitness); err != nil {
+		return err
+	}
+
+	log.Info().Msg("proof verified")
+	return nil
+}
+
+func (p *prover) write(name string, obj io.WriterTo) error {
+	f, err := os.Create(p.path(name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := obj.WriteTo(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	log.Debug().Int64("written", n).Str("file", name).Msg("bytes")
+	return nil
+}
+
+func (p *prover) read(name string, obj io.ReaderFrom) error {
+	f, err := os.Open(p.path(name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := obj.ReadFrom(f); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	utils "circuits/utils"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/require"
+)
+
+// x*x == threshold
+type squareCircuit struct {
+	X         frontend.Variable
+	Threshold frontend.Variable `gnark:",public"`
+}
+
+func (circuit *squareCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(circuit.X, circuit.X), circuit.Threshold)
+	return nil
+}
+
+func loadSquare(params string, threshold int) (frontend.Circuit, frontend.Circuit, error) {
+	raw, err := os.ReadFile(params)
+	if err != nil {
+		return nil, nil, err
+	}
+	var data struct {
+		X int `json:"x"`
+	}
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, nil, err
+	}
+	return &squareCircuit{}, &squareCircuit{X: data.X, Threshold: threshold}, nil
+}
+
+func writeParams(t *testing.T) string {
+	params := filepath.Join(t.TempDir(), "params.json")
+	require.NoError(t, os.WriteFile(params, []byte(`{"x": 3}`), 0644))
+	return params
+}
+
+// writes an insecure srs sized for the square circuit
+func writeTestSRS(t *testing.T, dir string) string {
+	builder, err := utils.BackendBuilder("plonk")
+	require.NoError(t, err)
+	ccs, err := frontend.Compile(curveID.ScalarField(), builder, &squareCircuit{})
+	require.NoError(t, err)
+	srs, err := utils.NewTestSRS(ccs)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "srs.kzg")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = srs.WriteTo(f)
+	require.NoError(t, err)
+	return path
+}
+
+func TestRoundTrip(t *testing.T) {
+	params := writeParams(t)
+
+	for _, backend := range []string{"groth16", "plonk"} {
+		t.Run(backend, func(t *testing.T) {
+			dir := t.TempDir()
+			srs := ""
+			if backend == "plonk" {
+				srs = writeTestSRS(t, t.TempDir())
+			}
+			p := newCircuitProver(backend, dir, srs, "square", loadSquare)
+
+			require.NoError(t, p.compile(params))
+			require.NoError(t, p.setup())
+			require.NoError(t, p.prove(params, 9))
+			require.NoError(t, p.verify())
+
+			// no proof of a false statement
+			require.Error(t, p.prove(params, 10))
+
+			// tampered public witness
+			publicWitness, err := os.ReadFile(p.path(witnessFile))
+			require.NoError(t, err)
+			publicWitness[len(publicWitness)-1] ^= 1
+			require.NoError(t, os.WriteFile(p.path(witnessFile), publicWitness, 0644))
+			require.Error(t, p.verify())
+		})
+	}
+}
+
+func TestSetupRequiresSRS(t *testing.T) {
+	params := writeParams(t)
+
+	p := newCircuitProver("plonk", t.TempDir(), "", "square", loadSquare)
+	require.NoError(t, p.compile(params))
+	require.True(t, errors.Is(p.setup(), utils.ErrMissingSRS))
+}
+
+// positions are private, params that only differ in the offsets share constraint system and keys
+func TestOracleIgnoresPositions(t *testing.T) {
+	params := filepath.Join("testdata", "params.json")
+
+	raw, err := os.ReadFile(params)
+	require.NoError(t, err)
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &data))
+	for _, key := range []string{"substring_start", "substring_end", "value_start", "value_end"} {
+		var v int
+		_, err := fmt.Sscan(data[key].(string), &v)
+		require.NoError(t, err)
+		data[key] = fmt.Sprint(v - 4)
+	}
+	raw, err = json.Marshal(data)
+	require.NoError(t, err)
+	shifted := filepath.Join(t.TempDir(), "params.json")
+	require.NoError(t, os.WriteFile(shifted, raw, 0644))
+
+	p := newProver("groth16", t.TempDir(), "")
+	var fingerprints []string
+	for _, path := range []string{params, shifted} {
+		circuit, _, err := p.load(path, 38001)
+		require.NoError(t, err)
+		ccs, err := p.compileCircuit(circuit)
+		require.NoError(t, err)
+		fingerprint, err := utils.CircuitFingerprint(ccs)
+		require.NoError(t, err)
+		fingerprints = append(fingerprints, fingerprint)
+	}
+	require.Equal(t, fingerprints[0], fingerprints[1])
+
+	// the witness of the parameter file solves the circuit of the shifted offsets
+	circuit, _, err := p.load(shifted, 0)
+	require.NoError(t, err)
+	_, assignment, err := p.load(params, 38001)
+	require.NoError(t, err)
+	require.NoError(t, test.IsSolved(circuit, assignment, curveID.ScalarField()))
+}
@@ -1,6 +1,10 @@
 package comparator
 
-import "github.com/consensys/gnark/frontend"
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+)
 
 // substring evaluation
 type SubstringWrapper struct {
@@ -13,17 +17,75 @@ type SubstringWrapper struct {
 // Define declares the circuit's constraints
 func (circuit *SubstringWrapper) Define(api frontend.API) error {
 
-	extractedSubstring := circuit.PlainChunks[circuit.SubstringStart:circuit.SubstringEnd]
-	SubstringMatch(api, circuit.Substring, extractedSubstring, 0, len(circuit.Substring))
+	return SubstringMatch(api, circuit.Substring, circuit.PlainChunks, circuit.SubstringStart, circuit.SubstringEnd)
+}
+
+// substring evaluation at a private offset
+type SubstringSearchWrapper struct {
+	PlainChunks []frontend.Variable
+	Offset      frontend.Variable
+	Substring   []frontend.Variable `gnark:",public"`
+	From        int                 `gnark:",public"`
+	To          int                 `gnark:",public"`
+}
+
+// Define declares the circuit's constraints
+func (circuit *SubstringSearchWrapper) Define(api frontend.API) error {
+
+	return SubstringMatchAt(api, circuit.Substring, circuit.PlainChunks, circuit.Offset, circuit.From, circuit.To)
+}
+
+// gnark substringmatch circuit, totalString[from:to] must equal substring
+func SubstringMatch(api frontend.API, substring, totalString []frontend.Variable, from, to int) error {
+	if from < 0 || to > len(totalString) || to-from != len(substring) {
+		return fmt.Errorf("comparator: window [%d, %d) does not hold %d bytes of %d", from, to, len(substring), len(totalString))
+	}
+	for i := range substring {
+		api.AssertIsEqual(substring[i], totalString[from+i])
+	}
+	return nil
+}
+
+// bytes packed into one field element
+const packSize = 31
+
+// substring occurs at the private offset, which must lie in [from, to-len(substring)]
+// bytes of totalString must be range checked, e.g. by the record decryption
+func SubstringMatchAt(api frontend.API, substring, totalString []frontend.Variable, offset frontend.Variable, from, to int) error {
 
+	positions := to - from - len(substring) + 1
+	if from < 0 || to > len(totalString) || len(substring) == 0 || positions <= 0 {
+		return fmt.Errorf("comparator: window [%d, %d) cannot hold %d bytes of %d", from, to, len(substring), len(totalString))
+	}
+
+	// one selector per candidate position, exactly one matches the offset
+	sel := make([]frontend.Variable, positions)
+	count := frontend.Variable(0)
+	for p := range sel {
+		sel[p] = api.IsZero(api.Sub(offset, from+p))
+		count = api.Add(count, sel[p])
+	}
+	api.AssertIsEqual(count, 1)
+
+	// compare packed substring chunks, packing is linear so every position costs one product per chunk
+	for c := 0; c < len(substring); c += packSize {
+		end := min(c+packSize, len(substring))
+		selected := frontend.Variable(0)
+		for p := range sel {
+			selected = api.Add(selected, api.Mul(sel[p], pack(api, totalString[from+p+c:from+p+end])))
+		}
+		api.AssertIsEqual(selected, pack(api, substring[c:end]))
+	}
 	return nil
 }
 
-// gnark substringmatch circuit
-func SubstringMatch(api frontend.API, substring, totalString []frontend.Variable, from, to int) {
-	for i := 0; i < len(substring); i++ {
-		api.AssertIsEqual(substring[i], totalString[i])
+// little endian base 256 combination
+func pack(api frontend.API, in []frontend.Variable) frontend.Variable {
+	sum := frontend.Variable(0)
+	for i := len(in) - 1; i >= 0; i-- {
+		sum = api.Add(api.Mul(sum, 256), in[i])
 	}
+	return sum
 }
 
 // gt/ lt evaluation
@@ -40,9 +102,9 @@ func (circuit *GTLTWrapper) Define(api frontend.API) error {
 	return nil
 }
 
-// it must hold v1 > v2 for GreaterThan to succeed
-// fails if v2 > v1
-// valueInteger > circuit.Threshold
+// it must hold v1 >= v2 for GreaterThan to succeed
+// fails if v2 > v1, see AssertPolicy for strict and bounded comparisons
+// valueInteger >= circuit.Threshold
 func GreaterThan(api frontend.API, v1, v2 frontend.Variable) {
 	api.AssertIsLessOrEqual(v2, v1)
 }
@@ -3,10 +3,13 @@ package comparator
 import (
 	utils "circuits/utils"
 	"encoding/hex"
+	"strings"
 	"testing"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
 	"github.com/consensys/gnark/test"
 )
 
@@ -71,3 +74,85 @@ func TestAES128Proving(t *testing.T) {
 	// Proof successfully generated
 	assert.ProverSucceeded(&circuit, &assignment)
 }
+
+// Test for the from/to window and the private offset search
+func TestSubstringSearch(t *testing.T) {
+
+	plain := `0,561 Euro"},"price":"38002.2","currency":"price"`
+	substring := `"price":`
+	offset := strings.Index(plain, substring)
+
+	setup := func(from, to, offset int) (SubstringSearchWrapper, SubstringSearchWrapper) {
+		assignment := SubstringSearchWrapper{
+			PlainChunks: make([]frontend.Variable, len(plain)),
+			Offset:      offset,
+			Substring:   make([]frontend.Variable, len(substring)),
+			From:        from,
+			To:          to,
+		}
+		for i := range plain {
+			assignment.PlainChunks[i] = plain[i]
+		}
+		for i := range substring {
+			assignment.Substring[i] = substring[i]
+		}
+		circuit := SubstringSearchWrapper{
+			PlainChunks: make([]frontend.Variable, len(plain)),
+			Substring:   make([]frontend.Variable, len(substring)),
+			From:        from,
+			To:          to,
+		}
+		return circuit, assignment
+	}
+
+	// offset inside the full plaintext and inside a window
+	for _, w := range [][2]int{{0, len(plain)}, {offset - 3, offset + len(substring)}} {
+		circuit, assignment := setup(w[0], w[1], offset)
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatal(w, err)
+		}
+	}
+
+	invalid := map[string][3]int{
+		// "price" without the colon
+		"no match": {0, len(plain), strings.LastIndex(plain, `"price"`)},
+		// offset left of the window
+		"before window": {offset + 1, len(plain), offset},
+		// match does not fit into the window
+		"after window": {0, offset + len(substring) - 1, offset},
+	}
+	for name, v := range invalid {
+		circuit, assignment := setup(v[0], v[1], v[2])
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+			t.Fatal(name, "expected search to fail")
+		}
+	}
+
+	// the window of SubstringMatch is respected
+	circuit, assignment := setupAES128Wrapper()
+	circuit.SubstringStart, circuit.SubstringEnd = 12, 19
+	assignment.SubstringStart, assignment.SubstringEnd = 12, 19
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected shifted window to fail")
+	}
+}
+
+// windows that cannot hold the substring are rejected when the circuit is defined
+func TestSubstringWindowErrors(t *testing.T) {
+
+	circuit, _ := setupAES128Wrapper()
+	circuit.SubstringEnd++
+	if _, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuit); err == nil {
+		t.Fatal("expected window of the wrong size to fail")
+	}
+
+	search := SubstringSearchWrapper{
+		PlainChunks: make([]frontend.Variable, 8),
+		Substring:   make([]frontend.Variable, 4),
+		From:        6,
+		To:          8,
+	}
+	if _, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &search); err == nil {
+		t.Fatal("expected window shorter than the substring to fail")
+	}
+}
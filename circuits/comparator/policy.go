@@ -0,0 +1,120 @@
+package comparator
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+)
+
+// numeric policy operators
+type Op int
+
+const (
+	OpGreaterOrEqual Op = iota // value >= bound, former GreaterThan
+	OpGreaterThan              // value > bound
+	OpLessOrEqual              // value <= bound
+	OpLessThan                 // value < bound
+	OpEqual                    // value == bound
+	OpNotEqual                 // value != bound
+	OpInRange                  // lo <= value <= hi
+	OpInSet                    // value is one of the bounds
+)
+
+// default bit width of values and bounds
+const DefaultNbBits = 64
+
+// numeric policy on a value, values and bounds are unsigned integers of NbBits bits
+type Policy struct {
+	Op     Op
+	NbBits int                 // 0 selects DefaultNbBits
+	Bounds []frontend.Variable // threshold, [lo, hi] or set members
+}
+
+// policy evaluation
+type PolicyWrapper struct {
+	Value  frontend.Variable   `gnark:",public"`
+	Bounds []frontend.Variable `gnark:",public"`
+	Op     Op
+	NbBits int
+}
+
+// Define declares the circuit's constraints
+func (circuit *PolicyWrapper) Define(api frontend.API) error {
+	return AssertPolicy(api, circuit.Value, Policy{Op: circuit.Op, NbBits: circuit.NbBits, Bounds: circuit.Bounds})
+}
+
+// asserts that value satisfies the policy
+func AssertPolicy(api frontend.API, value frontend.Variable, p Policy) error {
+
+	nbBits := p.NbBits
+	if nbBits == 0 {
+		nbBits = DefaultNbBits
+	}
+	if nbBits >= api.Compiler().FieldBitLen()-1 {
+		return fmt.Errorf("comparator: bit width %d exceeds the field", nbBits)
+	}
+
+	switch p.Op {
+	case OpGreaterOrEqual, OpGreaterThan, OpLessOrEqual, OpLessThan, OpEqual, OpNotEqual:
+		if len(p.Bounds) != 1 {
+			return fmt.Errorf("comparator: operator %d takes one bound, got %d", p.Op, len(p.Bounds))
+		}
+	case OpInRange:
+		if len(p.Bounds) != 2 {
+			return fmt.Errorf("comparator: range takes two bounds, got %d", len(p.Bounds))
+		}
+	case OpInSet:
+		if len(p.Bounds) == 0 {
+			return fmt.Errorf("comparator: empty set")
+		}
+	default:
+		return fmt.Errorf("comparator: unknown operator %d", p.Op)
+	}
+
+	assertBits(api, value, nbBits)
+	for _, b := range p.Bounds {
+		assertBits(api, b, nbBits)
+	}
+
+	switch p.Op {
+	case OpGreaterOrEqual:
+		AssertLessOrEqual(api, p.Bounds[0], value, nbBits)
+	case OpGreaterThan:
+		AssertLessThan(api, p.Bounds[0], value, nbBits)
+	case OpLessOrEqual:
+		AssertLessOrEqual(api, value, p.Bounds[0], nbBits)
+	case OpLessThan:
+		AssertLessThan(api, value, p.Bounds[0], nbBits)
+	case OpEqual:
+		api.AssertIsEqual(value, p.Bounds[0])
+	case OpNotEqual:
+		api.AssertIsEqual(api.IsZero(api.Sub(value, p.Bounds[0])), 0)
+	case OpInRange:
+		AssertLessOrEqual(api, p.Bounds[0], value, nbBits)
+		AssertLessOrEqual(api, value, p.Bounds[1], nbBits)
+	case OpInSet:
+		prod := frontend.Variable(1)
+		for _, s := range p.Bounds {
+			prod = api.Mul(prod, api.Sub(value, s))
+		}
+		api.AssertIsEqual(prod, 0)
+	}
+
+	return nil
+}
+
+// a <= b for a, b of nbBits bits, b - a must not wrap around
+func AssertLessOrEqual(api frontend.API, a, b frontend.Variable, nbBits int) {
+	assertBits(api, api.Sub(b, a), nbBits)
+}
+
+// a < b for a, b of nbBits bits
+func AssertLessThan(api frontend.API, a, b frontend.Variable, nbBits int) {
+	assertBits(api, api.Sub(api.Sub(b, a), 1), nbBits)
+}
+
+// asserts 0 <= v < 2^nbBits
+func assertBits(api frontend.API, v frontend.Variable, nbBits int) {
+	bits.ToBinary(api, v, bits.WithNbDigits(nbBits))
+}
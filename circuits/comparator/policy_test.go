@@ -0,0 +1,87 @@
+package comparator
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+type policyCase struct {
+	op     Op
+	nbBits int
+	value  int
+	bounds []int
+}
+
+func setupPolicyWrapper(c policyCase) (PolicyWrapper, PolicyWrapper) {
+
+	assignment := PolicyWrapper{
+		Value:  c.value,
+		Bounds: make([]frontend.Variable, len(c.bounds)),
+	}
+	for i, b := range c.bounds {
+		assignment.Bounds[i] = b
+	}
+
+	circuit := PolicyWrapper{
+		Bounds: make([]frontend.Variable, len(c.bounds)),
+		Op:     c.op,
+		NbBits: c.nbBits,
+	}
+
+	return circuit, assignment
+}
+
+// Test for Solving
+func TestPolicySolving(t *testing.T) {
+
+	valid := []policyCase{
+		{OpGreaterOrEqual, 0, 38002, []int{38002}},
+		{OpGreaterThan, 0, 38002, []int{38001}},
+		{OpLessOrEqual, 0, 38002, []int{38002}},
+		{OpLessThan, 0, 38002, []int{38003}},
+		{OpEqual, 0, 38002, []int{38002}},
+		{OpNotEqual, 0, 38002, []int{0}},
+		{OpInRange, 16, 38002, []int{38000, 38002}},
+		{OpInRange, 16, 0, []int{0, 65535}},
+		{OpInSet, 0, 38002, []int{1, 38002, 5}},
+	}
+	for _, c := range valid {
+		circuit, assignment := setupPolicyWrapper(c)
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatal(c, err)
+		}
+	}
+}
+
+func TestPolicyRejects(t *testing.T) {
+
+	invalid := []policyCase{
+		{OpGreaterOrEqual, 0, 38002, []int{38003}},
+		{OpGreaterThan, 0, 38002, []int{38002}},
+		{OpLessOrEqual, 0, 38002, []int{38001}},
+		{OpLessThan, 0, 38002, []int{38002}},
+		{OpEqual, 0, 38002, []int{38001}},
+		{OpNotEqual, 0, 38002, []int{38002}},
+		{OpInRange, 0, 38003, []int{38000, 38002}},
+		{OpInRange, 0, 37999, []int{38000, 38002}},
+		{OpInSet, 0, 38002, []int{1, 38001, 5}},
+		// value exceeds the declared width
+		{OpGreaterOrEqual, 15, 38002, []int{0}},
+		{OpLessThan, 16, 65536, []int{65535}},
+	}
+	for _, c := range invalid {
+		circuit, assignment := setupPolicyWrapper(c)
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+			t.Fatal(c, "expected policy to fail")
+		}
+	}
+
+	// wrong number of bounds
+	circuit, assignment := setupPolicyWrapper(policyCase{OpInRange, 0, 38002, []int{38000}})
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected range with one bound to fail")
+	}
+}
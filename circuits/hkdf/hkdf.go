@@ -0,0 +1,129 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hkdf
+
+import (
+	hmac "circuits/hmac"
+	sha256 "circuits/sha256"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// derives the traffic key and iv from a traffic secret
+type HkdfWrapper struct {
+	Secret []frontend.Variable
+	Key    []frontend.Variable   `gnark:",public"`
+	Iv     [12]frontend.Variable `gnark:",public"`
+}
+
+// Define declares the circuit's constraints
+func (circuit *HkdfWrapper) Define(api frontend.API) error {
+
+	hkdf := NewHKDF(api)
+
+	key := hkdf.ExpandLabel(circuit.Secret, "key", nil, len(circuit.Key))
+	iv := hkdf.ExpandLabel(circuit.Secret, "iv", nil, 12)
+
+	// constraint check
+	for i := range circuit.Key {
+		api.AssertIsEqual(circuit.Key[i], key[i])
+	}
+	for i := range circuit.Iv {
+		api.AssertIsEqual(circuit.Iv[i], iv[i])
+	}
+
+	return nil
+}
+
+// hkdf of rfc5869 over hmac-sha256 or hmac-sha384
+type HKDF struct {
+	api    frontend.API
+	size   int
+	hasher sha256.Hasher
+}
+
+func NewHKDF(api frontend.API) HKDF {
+	return HKDF{api: api, size: 32}
+}
+
+// hmac-sha256 over digests of the given hasher, nil selects the bitwise sha256
+func NewHKDFWithHasher(api frontend.API, hasher sha256.Hasher) HKDF {
+	return HKDF{api: api, size: 32, hasher: hasher}
+}
+
+func NewHKDF384(api frontend.API) HKDF {
+	return HKDF{api: api, size: 48}
+}
+
+// hash output size
+func (h *HKDF) Size() int {
+	return h.size
+}
+
+// HMAC-Hash(key, data)
+func (h *HKDF) mac(key, data []frontend.Variable) []frontend.Variable {
+	m := hmac.NewHMACWithHasher(h.api, h.hasher)
+	if h.size == 48 {
+		out := m.OuterHash384(key, m.InnerHash384(key, data))
+		return out[:]
+	}
+	out := m.OuterHash(key, m.InnerHash(key, data))
+	return out[:]
+}
+
+// PRK = HMAC-Hash(salt, IKM), an empty salt equals a zero salt of hash length
+func (h *HKDF) Extract(salt, ikm []frontend.Variable) []frontend.Variable {
+	return h.mac(salt, ikm)
+}
+
+// OKM = T(1) || T(2) || ... truncated to length
+func (h *HKDF) Expand(prk, info []frontend.Variable, length int) []frontend.Variable {
+	if length > 255*h.size {
+		panic("hkdf: requested length too large")
+	}
+	okm := make([]frontend.Variable, 0, length)
+	var t []frontend.Variable
+	for i := 1; len(okm) < length; i++ {
+		// T(i) = HMAC-Hash(PRK, T(i-1) | info | i)
+		data := append(append(append([]frontend.Variable{}, t...), info...), i)
+		t = h.mac(prk, data)
+		okm = append(okm, t...)
+	}
+	return okm[:length]
+}
+
+// HKDF-Expand-Label(Secret, Label, Context, Length), rfc8446 section 7.1
+func (h *HKDF) ExpandLabel(secret []frontend.Variable, label string, context []frontend.Variable, length int) []frontend.Variable {
+	return h.Expand(secret, HkdfLabel(label, context, length), length)
+}
+
+// Derive-Secret(Secret, Label, Messages) over the transcript hash
+func (h *HKDF) DeriveSecret(secret []frontend.Variable, label string, transcriptHash []frontend.Variable) []frontend.Variable {
+	return h.ExpandLabel(secret, label, transcriptHash, h.size)
+}
+
+// HkdfLabel structure with the "tls13 " prefix, label bytes are constants
+func HkdfLabel(label string, context []frontend.Variable, length int) []frontend.Variable {
+	full := "tls13 " + label
+	out := make([]frontend.Variable, 0, 2+1+len(full)+1+len(context))
+	out = append(out, (length>>8)&0xff, length&0xff, len(full))
+	for i := 0; i < len(full); i++ {
+		out = append(out, int(full[i]))
+	}
+	out = append(out, len(context))
+	return append(out, context...)
+}
@@ -0,0 +1,152 @@
+package hkdf
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"testing"
+
+	utils "circuits/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"golang.org/x/crypto/hkdf"
+)
+
+type extractExpandCircuit struct {
+	Salt []frontend.Variable
+	Ikm  []frontend.Variable
+	Info []frontend.Variable
+	Prk  []frontend.Variable `gnark:",public"`
+	Okm  []frontend.Variable `gnark:",public"`
+}
+
+func (circuit *extractExpandCircuit) Define(api frontend.API) error {
+	hkdf := NewHKDF(api)
+	prk := hkdf.Extract(circuit.Salt, circuit.Ikm)
+	okm := hkdf.Expand(prk, circuit.Info, len(circuit.Okm))
+	for i := range circuit.Prk {
+		api.AssertIsEqual(circuit.Prk[i], prk[i])
+	}
+	for i := range circuit.Okm {
+		api.AssertIsEqual(circuit.Okm[i], okm[i])
+	}
+	return nil
+}
+
+func toVariables(in []byte) []frontend.Variable {
+	out := make([]frontend.Variable, len(in))
+	for i := range in {
+		out[i] = in[i]
+	}
+	return out
+}
+
+// rfc5869 appendix A.1, the okm spans two blocks
+func TestExtractExpandSolving(t *testing.T) {
+
+	salt := utils.MustHex("000102030405060708090a0b0c")
+	ikm := utils.MustHex("0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b")
+	info := utils.MustHex("f0f1f2f3f4f5f6f7f8f9")
+	prk := utils.MustHex("077709362c2e32df0ddc3f0dc47bba6390b6c73bb50f9c3122ec844ad7c2b3e5")
+	okm := utils.MustHex("3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865")
+
+	assignment := extractExpandCircuit{
+		Salt: toVariables(salt),
+		Ikm:  toVariables(ikm),
+		Info: toVariables(info),
+		Prk:  toVariables(prk),
+		Okm:  toVariables(okm),
+	}
+	circuit := extractExpandCircuit{
+		Salt: make([]frontend.Variable, len(salt)),
+		Ikm:  make([]frontend.Variable, len(ikm)),
+		Info: make([]frontend.Variable, len(info)),
+		Prk:  make([]frontend.Variable, len(prk)),
+		Okm:  make([]frontend.Variable, len(okm)),
+	}
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// host side HKDF-Expand-Label
+func expandLabel(h func() hash.Hash, secret []byte, label string, length int) []byte {
+	info := []byte{byte(length >> 8), byte(length), byte(6 + len(label))}
+	info = append(info, "tls13 "+label...)
+	info = append(info, 0)
+	out := make([]byte, length)
+	if _, err := hkdf.Expand(h, secret, info).Read(out); err != nil {
+		panic(err)
+	}
+	return out
+}
+
+func setupHkdfWrapper(h func() hash.Hash, keyLen int) (HkdfWrapper, HkdfWrapper) {
+
+	secret := make([]byte, h().Size())
+	for i := range secret {
+		secret[i] = byte(5 * i)
+	}
+	key := expandLabel(h, secret, "key", keyLen)
+	iv := expandLabel(h, secret, "iv", 12)
+
+	assignment := HkdfWrapper{
+		Secret: toVariables(secret),
+		Key:    toVariables(key),
+	}
+	for i := range assignment.Iv {
+		assignment.Iv[i] = iv[i]
+	}
+	circuit := HkdfWrapper{
+		Secret: make([]frontend.Variable, len(secret)),
+		Key:    make([]frontend.Variable, keyLen),
+	}
+	return circuit, assignment
+}
+
+// Test for Solving
+func TestHkdfWrapperSolving(t *testing.T) {
+	circuit, assignment := setupHkdfWrapper(sha256.New, 16)
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// wrong iv
+	assignment.Iv[0] = (assignment.Iv[0].(byte) + 1)
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected wrong iv to fail")
+	}
+}
+
+type expandLabel384Circuit struct {
+	Secret []frontend.Variable
+	Key    []frontend.Variable `gnark:",public"`
+}
+
+func (circuit *expandLabel384Circuit) Define(api frontend.API) error {
+	hkdf := NewHKDF384(api)
+	key := hkdf.ExpandLabel(circuit.Secret, "key", nil, len(circuit.Key))
+	for i := range circuit.Key {
+		api.AssertIsEqual(circuit.Key[i], key[i])
+	}
+	return nil
+}
+
+// Test for Solving
+func TestExpandLabel384Solving(t *testing.T) {
+	_, ref := setupHkdfWrapper(sha512.New384, 32)
+
+	assignment := expandLabel384Circuit{Secret: ref.Secret, Key: ref.Key}
+	circuit := expandLabel384Circuit{
+		Secret: make([]frontend.Variable, len(ref.Secret)),
+		Key:    make([]frontend.Variable, len(ref.Key)),
+	}
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
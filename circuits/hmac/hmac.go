@@ -10,6 +10,9 @@ import (
 
 const B = 64 // Assuming B is a constant and equals 64
 
+// sha384 block size
+const B384 = 128
+
 type HMACWrapper struct {
 	K        []frontend.Variable
 	Text     []frontend.Variable
@@ -30,18 +33,32 @@ func (circuit *HMACWrapper) Define(api frontend.API) error {
 	return nil
 }
 
+// sha256 is computed bitwise
 func NewHMAC(api frontend.API) HMAC {
 	return HMAC{api: api}
 }
 
+// sha256 digests are created by the given hasher, nil selects the bitwise sha256
+func NewHMACWithHasher(api frontend.API, hasher sha256.Hasher) HMAC {
+	return HMAC{api: api, hasher: hasher}
+}
+
 type HMAC struct {
-	api frontend.API
+	api    frontend.API
+	hasher sha256.Hasher
+}
+
+func (hmac *HMAC) newSHA256() sha256.Hash {
+	if hmac.hasher == nil {
+		hmac.hasher = sha256.NewHasher(hmac.api)
+	}
+	return hmac.hasher.New()
 }
 
 func (hmac *HMAC) InnerHash(key []frontend.Variable, text []frontend.Variable) [32]frontend.Variable {
 
 	// gadget imports
-	sha := sha256.NewSHA256(hmac.api)
+	sha := hmac.newSHA256()
 
 	expandedKey := make([]frontend.Variable, B)
 
@@ -85,7 +102,7 @@ func (hmac *HMAC) InnerHash(key []frontend.Variable, text []frontend.Variable) [
 
 func (hmac *HMAC) OuterHash(key []frontend.Variable, innerHash [32]frontend.Variable) [32]frontend.Variable {
 	// gadget imports
-	sha := sha256.NewSHA256(hmac.api)
+	sha := hmac.newSHA256()
 
 	// ExpandedKey will hold the outer padded key
 	expandedKey := make([]frontend.Variable, B)
@@ -133,3 +150,63 @@ func (hmac *HMAC) OuterHash(key []frontend.Variable, innerHash [32]frontend.Vari
 
 	return outerHash
 }
+
+type HMAC384Wrapper struct {
+	K        []frontend.Variable
+	Text     []frontend.Variable
+	Expected []frontend.Variable
+}
+
+func (circuit *HMAC384Wrapper) Define(api frontend.API) error {
+	hmac := NewHMAC(api)
+
+	innerHash := hmac.InnerHash384(circuit.K, circuit.Text)
+
+	HMAC := hmac.OuterHash384(circuit.K, innerHash)
+
+	// constraint check
+	for i := 0; i < len(circuit.Expected); i++ {
+		api.AssertIsEqual(circuit.Expected[i], HMAC[i])
+	}
+	return nil
+}
+
+// sha384((key ^ ipad) || text)
+func (hmac *HMAC) InnerHash384(key []frontend.Variable, text []frontend.Variable) [48]frontend.Variable {
+
+	sha := sha256.NewSHA384(hmac.api)
+	sha.Write(append(hmac.padKey384(key, 0x36), text...))
+
+	var innerHash [48]frontend.Variable
+	copy(innerHash[:], sha.Sum())
+	return innerHash
+}
+
+// sha384((key ^ opad) || innerHash)
+func (hmac *HMAC) OuterHash384(key []frontend.Variable, innerHash [48]frontend.Variable) [48]frontend.Variable {
+
+	sha := sha256.NewSHA384(hmac.api)
+	sha.Write(append(hmac.padKey384(key, 0x5c), innerHash[:]...))
+
+	var outerHash [48]frontend.Variable
+	copy(outerHash[:], sha.Sum())
+	return outerHash
+}
+
+// zero extends the key to the block size and xors the pad byte, keys longer than the block are hashed first (rfc2104)
+func (hmac *HMAC) padKey384(key []frontend.Variable, pad int) []frontend.Variable {
+	if len(key) > B384 {
+		sha := sha256.NewSHA384(hmac.api)
+		sha.Write(key)
+		key = sha.Sum()
+	}
+	out := make([]frontend.Variable, B384)
+	for i := range out {
+		var k frontend.Variable = 0
+		if i < len(key) {
+			k = key[i]
+		}
+		out[i] = utils.VariableXor(hmac.api, k, frontend.Variable(pad), 8)
+	}
+	return out
+}
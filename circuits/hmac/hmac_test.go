@@ -1,16 +1,20 @@
 package hmac
 
 import (
+	"bytes"
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/sha512"
 	"encoding/hex"
 	"encoding/json"
 	"testing"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/test"
 
+	sha256lookup "circuits/sha256lookup"
 	utils "circuits/utils"
 )
 
@@ -118,7 +122,87 @@ func TestHMACProving(t *testing.T) {
 	assert.ProverSucceeded(&circuit, &assignment)
 }
 
+// hmac over the lookup based sha256
+type hmacLookupWrapper struct {
+	HMACWrapper
+}
+
+func (circuit *hmacLookupWrapper) Define(api frontend.API) error {
+
+	hmac := NewHMACWithHasher(api, sha256lookup.NewSHA256(api))
+	innerHash := hmac.InnerHash(circuit.K, circuit.Text)
+	HMAC := hmac.OuterHash(circuit.K, innerHash)
+
+	for i := 0; i < len(circuit.Expected); i++ {
+		api.AssertIsEqual(circuit.Expected[i], HMAC[i])
+	}
+
+	return nil
+}
+
+func TestHMACLookupSolving(t *testing.T) {
+	circuit, assignment := setupHMACWrapper()
+
+	if err := test.IsSolved(&hmacLookupWrapper{circuit}, &hmacLookupWrapper{assignment}, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func BenchmarkHMACProof(b *testing.B) {
 	circuit, assignment := setupHMACWrapper()
 	utils.BenchProof(b, &circuit, &assignment)
 }
+
+// hmac-sha384 wrapper of key and text with the expected mac of crypto/hmac
+func setupHMAC384Wrapper(keyBytes, textBytes []byte) (HMAC384Wrapper, HMAC384Wrapper) {
+
+	h := hmac.New(sha512.New384, keyBytes)
+	h.Write(textBytes)
+	expected := h.Sum(nil)
+
+	assignment := HMAC384Wrapper{
+		K:        make([]frontend.Variable, len(keyBytes)),
+		Text:     make([]frontend.Variable, len(textBytes)),
+		Expected: make([]frontend.Variable, len(expected)),
+	}
+	for i := range keyBytes {
+		assignment.K[i] = keyBytes[i]
+	}
+	for i := range textBytes {
+		assignment.Text[i] = textBytes[i]
+	}
+	for i := range expected {
+		assignment.Expected[i] = expected[i]
+	}
+
+	circuit := HMAC384Wrapper{
+		K:        make([]frontend.Variable, len(keyBytes)),
+		Text:     make([]frontend.Variable, len(textBytes)),
+		Expected: make([]frontend.Variable, len(expected)),
+	}
+
+	return circuit, assignment
+}
+
+// Test for Solving
+func TestHMAC384Solving(t *testing.T) {
+
+	keyBytes, _ := hex.DecodeString("0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b")
+	circuit, assignment := setupHMAC384Wrapper(keyBytes, []byte("The quick brown fox"))
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// keys longer than the 128 byte block are hashed first, rfc4231 test case 6
+func TestHMAC384LongKey(t *testing.T) {
+
+	keyBytes := bytes.Repeat([]byte{0xaa}, 131)
+	circuit, assignment := setupHMAC384Wrapper(keyBytes, []byte("Test Using Larger Than Block-Size Key - Hash Key First"))
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package json
+
+import (
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// proves plaintext[ValueStart:ValueEnd] is the value of the key path
+type JsonWrapper struct {
+	Plaintext []frontend.Variable
+	Value     []frontend.Variable `gnark:",public"`
+	Field     Field
+}
+
+// Define declares the circuit's constraints
+func (circuit *JsonWrapper) Define(api frontend.API) error {
+
+	doc := NewJson(api, circuit.Plaintext)
+
+	value, err := doc.AssertField(circuit.Field)
+	if err != nil {
+		return err
+	}
+
+	// constraint check
+	if len(value) != len(circuit.Value) {
+		return fmt.Errorf("json: value length %d, expected %d", len(value), len(circuit.Value))
+	}
+	for i := range value {
+		api.AssertIsEqual(value[i], circuit.Value[i])
+	}
+
+	return nil
+}
+
+// claimed location of a key path, positions are absolute offsets into the plaintext
+type Field struct {
+	Path       []string // keys from the outermost object, e.g. data, price
+	KeyStarts  []int    // offset of the opening quote of every key of the path
+	ValueStart int      // first byte of the value, after the opening quote of string values
+	ValueEnd   int
+	Quoted     bool // string value instead of a number
+}
+
+// lexical state of a json document, the plaintext must start at the top level of the document
+type Json struct {
+	api       frontend.API
+	plaintext []frontend.Variable
+	// str[i] = 1 if a string is open before byte i, str[len(plaintext)] after the last byte
+	str []frontend.Variable
+	// depth[i] = number of objects and arrays open before byte i
+	depth []frontend.Variable
+}
+
+func NewJson(api frontend.API, plaintext []frontend.Variable) Json {
+
+	j := Json{
+		api:       api,
+		plaintext: plaintext,
+		str:       make([]frontend.Variable, len(plaintext)+1),
+		depth:     make([]frontend.Variable, len(plaintext)),
+	}
+
+	// string and escape flags and nesting depth before the current byte
+	var str, esc, depth frontend.Variable = 0, 0, 0
+	for i, b := range plaintext {
+		isQuote := api.IsZero(api.Sub(b, '"'))
+		isBackslash := api.IsZero(api.Sub(b, '\\'))
+		isOpen := api.Add(api.IsZero(api.Sub(b, '{')), api.IsZero(api.Sub(b, '[')))
+		isClose := api.Add(api.IsZero(api.Sub(b, '}')), api.IsZero(api.Sub(b, ']')))
+
+		// unescaped quotes toggle the string state
+		toggle := api.Mul(isQuote, api.Sub(1, esc))
+		nextStr := api.Xor(str, toggle)
+
+		j.str[i] = str
+		j.depth[i] = depth
+
+		// brackets count outside of strings only
+		outside := api.Sub(1, str)
+		depth = api.Add(depth, api.Mul(outside, api.Sub(isOpen, isClose)))
+
+		// backslash inside a string escapes the next byte unless escaped itself
+		esc = api.Mul(api.Mul(str, isBackslash), api.Sub(1, esc))
+		str = nextStr
+	}
+	j.str[len(plaintext)] = str
+
+	return j
+}
+
+// asserts the key path and its value at the claimed location and returns the value bytes
+// every key is an object member at its nesting depth, and the object holding key i+1 is the value of key i
+func (j *Json) AssertField(f Field) ([]frontend.Variable, error) {
+
+	api := j.api
+	n := len(j.plaintext)
+
+	if len(f.Path) == 0 || len(f.Path) != len(f.KeyStarts) {
+		return nil, fmt.Errorf("json: path and key positions differ in length")
+	}
+
+	// position after the colon of the last key
+	var afterColon int
+	for k, key := range f.Path {
+		start := f.KeyStarts[k]
+		colon := start + len(key) + 2
+		if start < 1 || colon+1 >= n {
+			return nil, fmt.Errorf("json: key %q out of range", key)
+		}
+		if k > 0 && start <= f.KeyStarts[k-1] {
+			return nil, fmt.Errorf("json: key positions not increasing")
+		}
+
+		// "key": is a complete string followed by a colon, at depth k+1
+		api.AssertIsEqual(j.plaintext[start], '"')
+		api.AssertIsEqual(j.str[start], 0)
+		api.AssertIsEqual(j.depth[start], k+1)
+		for i := 0; i < len(key); i++ {
+			api.AssertIsEqual(j.plaintext[start+1+i], int(key[i]))
+			api.AssertIsEqual(j.str[start+1+i], 1)
+		}
+		api.AssertIsEqual(j.plaintext[start+1+len(key)], '"')
+		api.AssertIsEqual(j.str[colon], 0)
+		api.AssertIsEqual(j.plaintext[colon], ':')
+
+		// a key directly follows an object opening or a member separator
+		prev := j.plaintext[start-1]
+		api.AssertIsEqual(api.Mul(api.Sub(prev, '{'), api.Sub(prev, ',')), 0)
+
+		if k < len(f.Path)-1 {
+			// the value is an object that stays open up to the next key
+			api.AssertIsEqual(j.plaintext[colon+1], '{')
+			for i := colon + 2; i <= f.KeyStarts[k+1]; i++ {
+				api.AssertIsLessOrEqual(k+2, j.depth[i])
+			}
+		}
+		afterColon = colon + 1
+	}
+
+	if f.ValueStart < afterColon || f.ValueEnd < f.ValueStart || f.ValueEnd >= n {
+		return nil, fmt.Errorf("json: value out of range")
+	}
+
+	if f.Quoted {
+		// "value" directly after the colon, the string closes at ValueEnd
+		if f.ValueStart != afterColon+1 {
+			return nil, fmt.Errorf("json: string value must follow the colon")
+		}
+		api.AssertIsEqual(j.plaintext[afterColon], '"')
+		for i := f.ValueStart; i <= f.ValueEnd; i++ {
+			api.AssertIsEqual(j.str[i], 1)
+		}
+		api.AssertIsEqual(j.plaintext[f.ValueEnd], '"')
+		api.AssertIsEqual(j.str[f.ValueEnd+1], 0)
+	} else {
+		// number directly after the colon, terminated by a member or container end
+		if f.ValueStart != afterColon {
+			return nil, fmt.Errorf("json: number value must follow the colon")
+		}
+		for i := f.ValueStart; i < f.ValueEnd; i++ {
+			api.AssertIsEqual(j.str[i], 0)
+			api.AssertIsEqual(api.IsZero(j.delimiter(j.plaintext[i])), 0)
+		}
+		api.AssertIsEqual(j.delimiter(j.plaintext[f.ValueEnd]), 0)
+	}
+
+	return j.plaintext[f.ValueStart:f.ValueEnd], nil
+}
+
+// zero for the bytes terminating a number
+func (j *Json) delimiter(b frontend.Variable) frontend.Variable {
+	api := j.api
+	return api.Mul(api.Mul(api.Sub(b, ','), api.Sub(b, '}')), api.Sub(b, ']'))
+}
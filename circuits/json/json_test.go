@@ -0,0 +1,112 @@
+package json
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+const jsonBody = `{"data":{"currency":"EUR","price":"38002.20"},"note":"x,\"price\":\"99\"","meta":{"id":[1,{"a":2}],"price":5}}`
+
+func setupJsonWrapper(field Field) (JsonWrapper, JsonWrapper) {
+
+	value := jsonBody[field.ValueStart:field.ValueEnd]
+
+	assignment := JsonWrapper{
+		Plaintext: make([]frontend.Variable, len(jsonBody)),
+		Value:     make([]frontend.Variable, len(value)),
+	}
+	for i := range jsonBody {
+		assignment.Plaintext[i] = jsonBody[i]
+	}
+	for i := range value {
+		assignment.Value[i] = value[i]
+	}
+
+	circuit := JsonWrapper{
+		Plaintext: make([]frontend.Variable, len(jsonBody)),
+		Value:     make([]frontend.Variable, len(value)),
+		Field:     field,
+	}
+
+	return circuit, assignment
+}
+
+// offset of the n-th occurrence of s
+func index(s string, n int) int {
+	idx := -1
+	for i := 0; i <= n; i++ {
+		idx += 1 + strings.Index(jsonBody[idx+1:], s)
+	}
+	return idx
+}
+
+// Test for Solving
+func TestJsonSolving(t *testing.T) {
+
+	valid := []Field{
+		{
+			Path:       []string{"data", "price"},
+			KeyStarts:  []int{index(`"data"`, 0), index(`"price"`, 0)},
+			ValueStart: index("38002", 0),
+			ValueEnd:   index("38002", 0) + len("38002.20"),
+			Quoted:     true,
+		},
+		{
+			Path:       []string{"meta", "price"},
+			KeyStarts:  []int{index(`"meta"`, 0), index(`"price"`, 1)},
+			ValueStart: index(`"price":5`, 0) + len(`"price":`),
+			ValueEnd:   index(`"price":5`, 0) + len(`"price":5`),
+		},
+	}
+	for _, f := range valid {
+		circuit, assignment := setupJsonWrapper(f)
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatal(f.Path, err)
+		}
+	}
+}
+
+func TestJsonRejectsMisplacedKeys(t *testing.T) {
+
+	invalid := map[string]Field{
+		// "price" inside the escaped note string
+		"key in string": {
+			Path:       []string{"price"},
+			KeyStarts:  []int{index(`\"price\"`, 0) + 1},
+			ValueStart: index(`\"99`, 0) + 2,
+			ValueEnd:   index(`\"99`, 0) + 4,
+		},
+		// price of the meta object claimed under data
+		"other object": {
+			Path:       []string{"data", "price"},
+			KeyStarts:  []int{index(`"data"`, 0), index(`"price"`, 1)},
+			ValueStart: index(`"price":5`, 0) + len(`"price":`),
+			ValueEnd:   index(`"price":5`, 0) + len(`"price":5`),
+		},
+		// price of the nested object without its parent
+		"wrong depth": {
+			Path:       []string{"price"},
+			KeyStarts:  []int{index(`"price"`, 0)},
+			ValueStart: index("38002", 0),
+			ValueEnd:   index("38002", 0) + len("38002.20"),
+			Quoted:     true,
+		},
+		// number value extended over the closing brace
+		"value past delimiter": {
+			Path:       []string{"meta", "price"},
+			KeyStarts:  []int{index(`"meta"`, 0), index(`"price"`, 1)},
+			ValueStart: index(`"price":5`, 0) + len(`"price":`),
+			ValueEnd:   index(`"price":5`, 0) + len(`"price":5}`),
+		},
+	}
+	for name, f := range invalid {
+		circuit, assignment := setupJsonWrapper(f)
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+			t.Fatal(name, "expected invalid field to fail")
+		}
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package kdc
 
 import (
+	hkdf "circuits/hkdf"
 	sha256 "circuits/sha256"
 	utils "circuits/utils"
 
@@ -34,6 +35,9 @@ type KdcWrapper struct {
 
 func (circuit *KdcWrapper) Define(api frontend.API) error {
 
+	// private inputs are bytes
+	utils.AssertIsBytes(api, circuit.DHSin[:])
+
 	tls13_kdc := NewTls13Kdc(api)
 	tls13_kdc.SetParams(
 		circuit.IntermediateHashHSopad,
@@ -53,6 +57,8 @@ func (circuit *KdcWrapper) Define(api frontend.API) error {
 
 type Tls13Kdc struct {
 	api                    frontend.API
+	hasher                 sha256.Hasher
+	keyLen                 int
 	DHSin                  [64]frontend.Variable
 	IntermediateHashHSopad [32]frontend.Variable // `gnark:",public"`
 	MSin                   [32]frontend.Variable // `gnark:",public"`
@@ -60,8 +66,23 @@ type Tls13Kdc struct {
 	TkXAPPin               [32]frontend.Variable // `gnark:",public"`
 }
 
+// sha256 is computed bitwise
 func NewTls13Kdc(api frontend.API) Tls13Kdc {
-	return Tls13Kdc{api: api}
+	return NewTls13KdcWithHasher(api, nil)
+}
+
+// sha256 digests are created by the given hasher, nil selects the bitwise sha256
+func NewTls13KdcWithHasher(api frontend.API, hasher sha256.Hasher) Tls13Kdc {
+	if hasher == nil {
+		hasher = sha256.NewHasher(api)
+	}
+	return Tls13Kdc{api: api, hasher: hasher, keyLen: 16}
+}
+
+// traffic key length, 16 bytes for TLS_AES_128_GCM_SHA256 and 32 bytes for TLS_CHACHA20_POLY1305_SHA256.
+// TkXAPPin must encode the same length in its HkdfLabel.
+func (circuit *Tls13Kdc) SetKeyLength(keyLen int) {
+	circuit.keyLen = keyLen
 }
 
 func (circuit *Tls13Kdc) SetParams(IntermediateHashHSopad, MSin, XATSin, TkXAPPin [32]frontend.Variable, DHSin [64]frontend.Variable) {
@@ -74,12 +95,30 @@ func (circuit *Tls13Kdc) SetParams(IntermediateHashHSopad, MSin, XATSin, TkXAPPi
 
 // Define declares the circuit's constraints
 func (circuit *Tls13Kdc) Derive() []frontend.Variable {
+	tk, _ := circuit.derive()
+	return tk
+}
+
+// derives the traffic key and the traffic iv of the same traffic secret
+func (circuit *Tls13Kdc) DeriveKeyIv() ([]frontend.Variable, [12]frontend.Variable) {
+	tk, XATS := circuit.derive()
+
+	// iv = HKDF-Expand-Label(XATS, "iv", "", 12), computed in-circuit
+	h := hkdf.NewHKDFWithHasher(circuit.api, circuit.hasher)
+	var iv [12]frontend.Variable
+	copy(iv[:], h.ExpandLabel(XATS[:], "iv", nil, 12))
+
+	return tk, iv
+}
+
+// returns the traffic key and the traffic secret
+func (circuit *Tls13Kdc) derive() ([]frontend.Variable, [32]frontend.Variable) {
 
 	// gadget imports
-	sha := sha256.NewSHA256(circuit.api)
+	sha := circuit.hasher.New()
 
 	// optimized shacal2
-	shacal := sha256.NewSHA256WithIV(circuit.api, circuit.IntermediateHashHSopad, 64)
+	shacal := circuit.hasher.NewWithIV(circuit.IntermediateHashHSopad, 64)
 	dHS := shacal.WriteReturn(circuit.DHSin[:])
 
 	// dHS xor opad, and concatenate with MSIn
@@ -105,5 +144,110 @@ func (circuit *Tls13Kdc) Derive() []frontend.Variable {
 	sha.Write(XATSopadConcattkXAPPin)
 	tkXAPP := sha.Sum()
 
-	return tkXAPP[:16]
+	return tkXAPP[:circuit.keyLen], XATS
+}
+
+type Kdc384Wrapper struct {
+	DHSin                  [128]frontend.Variable
+	IntermediateHashHSopad [64]frontend.Variable `gnark:",public"`
+	MSin                   [48]frontend.Variable `gnark:",public"`
+	XATSin                 [48]frontend.Variable `gnark:",public"`
+	TkXAPPin               [48]frontend.Variable `gnark:",public"`
+	TkXAPP                 [32]frontend.Variable `gnark:",public"`
+	IvXAPP                 [12]frontend.Variable `gnark:",public"`
+}
+
+func (circuit *Kdc384Wrapper) Define(api frontend.API) error {
+
+	// private inputs are bytes
+	utils.AssertIsBytes(api, circuit.DHSin[:])
+
+	tls13_kdc := NewTls13Kdc384(api)
+	tls13_kdc.SetParams(
+		circuit.IntermediateHashHSopad,
+		circuit.MSin,
+		circuit.XATSin,
+		circuit.TkXAPPin,
+		circuit.DHSin,
+	)
+	tk, iv := tls13_kdc.DeriveKeyIv()
+
+	for i := 0; i < 32; i++ {
+		api.AssertIsEqual(tk[i], circuit.TkXAPP[i])
+	}
+	for i := 0; i < 12; i++ {
+		api.AssertIsEqual(iv[i], circuit.IvXAPP[i])
+	}
+
+	return nil
+}
+
+// key schedule of TLS_AES_256_GCM_SHA384, the opad state is the full sha384 chaining value
+type Tls13Kdc384 struct {
+	api                    frontend.API
+	DHSin                  [128]frontend.Variable
+	IntermediateHashHSopad [64]frontend.Variable // `gnark:",public"`
+	MSin                   [48]frontend.Variable // `gnark:",public"`
+	XATSin                 [48]frontend.Variable // `gnark:",public"`
+	TkXAPPin               [48]frontend.Variable // `gnark:",public"`
+}
+
+func NewTls13Kdc384(api frontend.API) Tls13Kdc384 {
+	return Tls13Kdc384{api: api}
+}
+
+func (circuit *Tls13Kdc384) SetParams(IntermediateHashHSopad [64]frontend.Variable, MSin, XATSin, TkXAPPin [48]frontend.Variable, DHSin [128]frontend.Variable) {
+	circuit.DHSin = DHSin
+	circuit.IntermediateHashHSopad = IntermediateHashHSopad
+	circuit.MSin = MSin
+	circuit.XATSin = XATSin
+	circuit.TkXAPPin = TkXAPPin
+}
+
+// Define declares the circuit's constraints
+func (circuit *Tls13Kdc384) Derive() []frontend.Variable {
+	tk, _ := circuit.derive()
+	return tk
+}
+
+// derives the traffic key and the traffic iv of the same traffic secret
+func (circuit *Tls13Kdc384) DeriveKeyIv() ([]frontend.Variable, [12]frontend.Variable) {
+	tk, XATS := circuit.derive()
+
+	// iv = HKDF-Expand-Label(XATS, "iv", "", 12), computed in-circuit
+	h := hkdf.NewHKDF384(circuit.api)
+	var iv [12]frontend.Variable
+	copy(iv[:], h.ExpandLabel(XATS[:], "iv", nil, 12))
+
+	return tk, iv
+}
+
+// returns the traffic key and the traffic secret
+func (circuit *Tls13Kdc384) derive() ([]frontend.Variable, [48]frontend.Variable) {
+
+	// resume sha384 after the opad block
+	shacal := sha256.NewSHA384WithIV(circuit.api, circuit.IntermediateHashHSopad, 128)
+	state := shacal.WriteReturn(circuit.DHSin[:])
+	var dHS [48]frontend.Variable
+	copy(dHS[:], state[:48])
+
+	// compute MS
+	MS := circuit.outerHash(dHS, circuit.MSin)
+
+	// compute XATS
+	XATS := circuit.outerHash(MS, circuit.XATSin)
+
+	// traffic key
+	tkXAPP := circuit.outerHash(XATS, circuit.TkXAPPin)
+
+	return tkXAPP[:32], XATS
+}
+
+// sha384((key xor opad) || inner)
+func (circuit *Tls13Kdc384) outerHash(key, inner [48]frontend.Variable) [48]frontend.Variable {
+	sha := sha256.NewSHA384(circuit.api)
+	sha.Write(utils.OpadConcat384(circuit.api, key, inner))
+	var out [48]frontend.Variable
+	copy(out[:], sha.Sum())
+	return out
 }
@@ -1,13 +1,20 @@
 package kdc
 
 import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding"
 	"encoding/hex"
 	"testing"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
 	"github.com/consensys/gnark/test"
 
+	sha256lookup "circuits/sha256lookup"
 	utils "circuits/utils"
 )
 
@@ -105,3 +112,162 @@ func TestKdcWrapperProving(t *testing.T) {
 	// Proof successfully generated
 	assert.ProverSucceeded(&circuit, &assignment)
 }
+
+func hmac384(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(sha512.New384, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+	return mac.Sum(nil)
+}
+
+// sha384((key ^ ipad) || msg)
+func innerHash384(key, msg []byte) []byte {
+	block := make([]byte, 128)
+	copy(block, key)
+	h := sha512.New384()
+	for i := range block {
+		block[i] ^= 0x36
+	}
+	h.Write(block)
+	h.Write(msg)
+	return h.Sum(nil)
+}
+
+// key schedule values computed out of circuit
+func setupKdc384Wrapper() (Kdc384Wrapper, Kdc384Wrapper) {
+
+	hs := make([]byte, 48)
+	for i := range hs {
+		hs[i] = byte(3 * i)
+	}
+	transcriptHash := sha512.Sum384([]byte("ClientHello..server Finished"))
+	emptyHash := sha512.Sum384(nil)
+	label := func(l string, context []byte, length int) []byte {
+		b := []byte{byte(length >> 8), byte(length), byte(6 + len(l))}
+		b = append(b, "tls13 "+l...)
+		b = append(b, byte(len(context)))
+		return append(append(b, context...), 1)
+	}
+
+	dHSin := innerHash384(hs, label("derived", emptyHash[:], 48))
+	dHS := hmac384(hs, label("derived", emptyHash[:], 48))
+	MSin := innerHash384(dHS, make([]byte, 48))
+	MS := hmac384(dHS, make([]byte, 48))
+	SATSin := innerHash384(MS, label("s ap traffic", transcriptHash[:], 48))
+	SATS := hmac384(MS, label("s ap traffic", transcriptHash[:], 48))
+	tkSAPPin := innerHash384(SATS, label("key", nil, 32))
+	tkSAPP := hmac384(SATS, label("key", nil, 32))[:32]
+	ivSAPP := hmac384(SATS, label("iv", nil, 12))[:12]
+
+	// sha384 chaining value after the opad block
+	opad := make([]byte, 128)
+	copy(opad, hs)
+	for i := range opad {
+		opad[i] ^= 0x5c
+	}
+	h := sha512.New384()
+	h.Write(opad)
+	state, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+	intermediateHashHSopad := state[4 : 4+64]
+
+	// add padding
+	dHSinPadded := append(dHSin, utils.PadSha512(128+48)...)
+
+	var assignment Kdc384Wrapper
+	for i := range assignment.DHSin {
+		assignment.DHSin[i] = dHSinPadded[i]
+	}
+	for i := range assignment.IntermediateHashHSopad {
+		assignment.IntermediateHashHSopad[i] = intermediateHashHSopad[i]
+	}
+	for i := 0; i < 48; i++ {
+		assignment.MSin[i] = MSin[i]
+		assignment.XATSin[i] = SATSin[i]
+		assignment.TkXAPPin[i] = tkSAPPin[i]
+	}
+	for i := range assignment.TkXAPP {
+		assignment.TkXAPP[i] = tkSAPP[i]
+	}
+	for i := range assignment.IvXAPP {
+		assignment.IvXAPP[i] = ivSAPP[i]
+	}
+
+	var circuit Kdc384Wrapper
+
+	return circuit, assignment
+}
+
+// Test for Solving
+func TestKdc384WrapperSolving(t *testing.T) {
+	circuit, assignment := setupKdc384Wrapper()
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Test for out of range private inputs
+func TestKdcWrapperRejectsNonBytes(t *testing.T) {
+	circuit, assignment := setupKdcWrapper()
+	assignment.DHSin[0] = 256
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected out of range DHSin to fail")
+	}
+}
+
+// kdc over the lookup based sha256
+type kdcLookupWrapper struct {
+	KdcWrapper
+}
+
+func (circuit *kdcLookupWrapper) Define(api frontend.API) error {
+
+	utils.AssertIsBytes(api, circuit.DHSin[:])
+
+	tls13_kdc := NewTls13KdcWithHasher(api, sha256lookup.NewSHA256(api))
+	tls13_kdc.SetParams(
+		circuit.IntermediateHashHSopad,
+		circuit.MSin,
+		circuit.XATSin,
+		circuit.TkXAPPin,
+		circuit.DHSin,
+	)
+	tk := tls13_kdc.Derive()
+
+	for i := 0; i < 16; i++ {
+		api.AssertIsEqual(tk[i], circuit.TkXAPP[i])
+	}
+
+	return nil
+}
+
+func TestKdcLookupSolving(t *testing.T) {
+	circuit, assignment := setupKdcWrapper()
+
+	lookupCircuit := kdcLookupWrapper{circuit}
+	lookupAssignment := kdcLookupWrapper{assignment}
+	if err := test.IsSolved(&lookupCircuit, &lookupAssignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	lookupAssignment.TkXAPP[0] = (assignment.TkXAPP[0].(int) + 1) % 256
+	if err := test.IsSolved(&lookupCircuit, &lookupAssignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected wrong traffic key to fail")
+	}
+
+	// constraint counts of both sha256 implementations
+	circuits := map[string]frontend.Circuit{"bitwise": &circuit, "lookup": &lookupCircuit}
+	for name, c := range circuits {
+		r1css, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sparse, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Logf("%s: r1cs constraints %d, scs constraints %d", name, r1css.GetNbConstraints(), sparse.GetNbConstraints())
+	}
+}
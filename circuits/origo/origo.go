@@ -17,9 +17,15 @@ limitations under the License.
 package origo
 
 import (
+	aes128 "circuits/aes128"
+	aes128lookup "circuits/aes128lookup"
+	aes256 "circuits/aes256"
 	authtag "circuits/authtag"
+	chacha20 "circuits/chacha20"
 	kdc "circuits/kdc"
 	record "circuits/record"
+	utils "circuits/utils"
+	"fmt"
 
 	"github.com/consensys/gnark/frontend"
 )
@@ -37,25 +43,184 @@ type Tls13OracleWrapper struct {
 	Zeros     [16]frontend.Variable `gnark:",public"`
 	ECB0      [16]frontend.Variable `gnark:",public"`
 	ECBK      [16]frontend.Variable `gnark:",public"`
-	// record params
+	// record params, positions are private so that one circuit covers every offset inside the chunks
 	PlainChunks    []frontend.Variable
+	SubstringStart frontend.Variable
+	ValueStart     frontend.Variable
+	ValueEnd       frontend.Variable
 	Iv             [12]frontend.Variable `gnark:",public"`
 	CipherChunks   []frontend.Variable   `gnark:",public"`
 	ChunkIndex     frontend.Variable     `gnark:",public"`
 	Substring      []frontend.Variable   `gnark:",public"`
+	Separator      []frontend.Variable   `gnark:",public"`
+	MaxValueLen    int                   `gnark:",public"`
+	Threshold      frontend.Variable     `gnark:",public"`
+	SequenceNumber [8]frontend.Variable  `gnark:",public"`
+	// constraints per stage of Assert are counted into Stages at compile time, nil counts nothing
+	Stages *utils.Stages `gnark:"-"`
+}
+
+// Define declares the circuit's constraints
+func (circuit *Tls13OracleWrapper) Define(api frontend.API) error {
+
+	// private inputs are bytes
+	utils.AssertIsBytes(api, circuit.DHSin[:], circuit.PlainChunks)
+
+	// initialize circuit struct
+	oracle := NewTls13Oracle(api)
+	oracle.SetStages(circuit.Stages)
+
+	// set data
+	oracle.SetKdcParams(
+		circuit.IntermediateHashHSopad[:],
+		circuit.MSin[:],
+		circuit.SATSin[:],
+		circuit.TkSAPPin[:],
+		// circuit.TkCommit,
+		circuit.DHSin[:],
+	)
+
+	oracle.SetAuthtagParams(
+		circuit.IvCounter,
+		circuit.Zeros,
+		circuit.ECB0,
+		circuit.ECBK,
+	)
+
+	oracle.SetRecordSelectParams(
+		circuit.Iv,
+		circuit.PlainChunks,
+		circuit.CipherChunks,
+		circuit.Substring,
+		circuit.Separator,
+		circuit.ChunkIndex,
+		circuit.Threshold,
+		circuit.SubstringStart,
+		circuit.ValueStart,
+		circuit.ValueEnd,
+		circuit.MaxValueLen,
+		circuit.SequenceNumber,
+	)
+
+	// verify commitment
+	return oracle.Assert()
+}
+
+// oracle with the record tag verified in-circuit, ECB0 and ECBK are derived privately
+type Tls13OracleGHashWrapper struct {
+	// kdc params
+	DHSin                  [64]frontend.Variable
+	IntermediateHashHSopad [32]frontend.Variable `gnark:",public"`
+	MSin                   [32]frontend.Variable `gnark:",public"`
+	SATSin                 [32]frontend.Variable `gnark:",public"`
+	TkSAPPin               [32]frontend.Variable `gnark:",public"`
+	// authtag params
+	RecordHeader [5]frontend.Variable  `gnark:",public"`
+	Ciphertext   []frontend.Variable   `gnark:",public"`
+	Tag          [16]frontend.Variable `gnark:",public"`
+	ChunkOffset  int                   `gnark:",public"`
+	// record params
+	PlainChunks    []frontend.Variable
+	Iv             [12]frontend.Variable `gnark:",public"`
+	ChunkIndex     frontend.Variable     `gnark:",public"`
+	Substring      []frontend.Variable   `gnark:",public"`
 	SubstringStart int                   `gnark:",public"`
 	SubstringEnd   int                   `gnark:",public"`
 	ValueStart     int                   `gnark:",public"`
 	ValueEnd       int                   `gnark:",public"`
 	Threshold      frontend.Variable     `gnark:",public"`
 	SequenceNumber [8]frontend.Variable  `gnark:",public"`
+	// constraints per stage of Assert are counted into Stages at compile time, nil counts nothing
+	Stages *utils.Stages `gnark:"-"`
 }
 
 // Define declares the circuit's constraints
-func (circuit *Tls13OracleWrapper) Define(api frontend.API) error {
+func (circuit *Tls13OracleGHashWrapper) Define(api frontend.API) error {
+
+	// private inputs are bytes
+	utils.AssertIsBytes(api, circuit.DHSin[:], circuit.PlainChunks)
+
+	// initialize circuit struct
+	oracle := NewTls13Oracle(api)
+	oracle.SetStages(circuit.Stages)
+
+	// set data
+	oracle.SetKdcParams(
+		circuit.IntermediateHashHSopad[:],
+		circuit.MSin[:],
+		circuit.SATSin[:],
+		circuit.TkSAPPin[:],
+		circuit.DHSin[:],
+	)
+
+	oracle.SetTagParams(
+		circuit.RecordHeader,
+		circuit.Ciphertext,
+		circuit.Tag,
+		circuit.ChunkOffset,
+	)
+
+	// selected chunks are part of the authenticated ciphertext
+	cipherChunks := circuit.Ciphertext[circuit.ChunkOffset : circuit.ChunkOffset+len(circuit.PlainChunks)]
+
+	oracle.SetRecordParams(
+		circuit.Iv,
+		circuit.PlainChunks,
+		cipherChunks,
+		circuit.Substring,
+		circuit.ChunkIndex,
+		circuit.Threshold,
+		circuit.SubstringStart,
+		circuit.SubstringEnd,
+		circuit.ValueStart,
+		circuit.ValueEnd,
+		circuit.SequenceNumber,
+	)
+
+	// verify commitment
+	return oracle.Assert()
+}
+
+// oracle parameterized by the negotiated cipher suite, the record tag is verified in-circuit
+// kdc params are sized by the suite hash, 32 byte blocks for sha256 and 64 byte blocks for sha384
+type Tls13OracleSuiteWrapper struct {
+	CipherSuite uint16
+	// kdc params
+	DHSin                  []frontend.Variable
+	IntermediateHashHSopad []frontend.Variable `gnark:",public"`
+	MSin                   []frontend.Variable `gnark:",public"`
+	SATSin                 []frontend.Variable `gnark:",public"`
+	TkSAPPin               []frontend.Variable `gnark:",public"`
+	// authtag params
+	RecordHeader [5]frontend.Variable  `gnark:",public"`
+	Ciphertext   []frontend.Variable   `gnark:",public"`
+	Tag          [16]frontend.Variable `gnark:",public"`
+	ChunkOffset  int                   `gnark:",public"`
+	// record params
+	PlainChunks    []frontend.Variable
+	Iv             [12]frontend.Variable `gnark:",public"`
+	ChunkIndex     frontend.Variable     `gnark:",public"`
+	Substring      []frontend.Variable   `gnark:",public"`
+	SubstringStart int                   `gnark:",public"`
+	SubstringEnd   int                   `gnark:",public"`
+	ValueStart     int                   `gnark:",public"`
+	ValueEnd       int                   `gnark:",public"`
+	Threshold      frontend.Variable     `gnark:",public"`
+	SequenceNumber [8]frontend.Variable  `gnark:",public"`
+	// constraints per stage of Assert are counted into Stages at compile time, nil counts nothing
+	Stages *utils.Stages `gnark:"-"`
+}
+
+// Define declares the circuit's constraints
+func (circuit *Tls13OracleSuiteWrapper) Define(api frontend.API) error {
+
+	// private inputs are bytes
+	utils.AssertIsBytes(api, circuit.DHSin, circuit.PlainChunks)
 
 	// initialize circuit struct
 	oracle := NewTls13Oracle(api)
+	oracle.SetStages(circuit.Stages)
+	oracle.SetCipherSuite(circuit.CipherSuite)
 
 	// set data
 	oracle.SetKdcParams(
@@ -63,21 +228,23 @@ func (circuit *Tls13OracleWrapper) Define(api frontend.API) error {
 		circuit.MSin,
 		circuit.SATSin,
 		circuit.TkSAPPin,
-		// circuit.TkCommit,
 		circuit.DHSin,
 	)
 
-	oracle.SetAuthtagParams(
-		circuit.IvCounter,
-		circuit.Zeros,
-		circuit.ECB0,
-		circuit.ECBK,
+	oracle.SetTagParams(
+		circuit.RecordHeader,
+		circuit.Ciphertext,
+		circuit.Tag,
+		circuit.ChunkOffset,
 	)
 
+	// selected chunks are part of the authenticated ciphertext
+	cipherChunks := circuit.Ciphertext[circuit.ChunkOffset : circuit.ChunkOffset+len(circuit.PlainChunks)]
+
 	oracle.SetRecordParams(
 		circuit.Iv,
 		circuit.PlainChunks,
-		circuit.CipherChunks,
+		cipherChunks,
 		circuit.Substring,
 		circuit.ChunkIndex,
 		circuit.Threshold,
@@ -89,20 +256,22 @@ func (circuit *Tls13OracleWrapper) Define(api frontend.API) error {
 	)
 
 	// verify commitment
-	oracle.Assert()
-
-	return nil
+	return oracle.Assert()
 }
 
 type Tls13Oracle struct {
 	api frontend.API
+	aes aes128.AES
+
+	// negotiated cipher suite
+	CipherSuite uint16
 
 	// kdc params
-	DHSin                  [64]frontend.Variable
-	IntermediateHashHSopad [32]frontend.Variable // `gnark:",public"`
-	MSin                   [32]frontend.Variable // `gnark:",public"`
-	XATSin                 [32]frontend.Variable // `gnark:",public"`
-	TkXAPPin               [32]frontend.Variable // `gnark:",public"`
+	DHSin                  []frontend.Variable
+	IntermediateHashHSopad []frontend.Variable // `gnark:",public"`
+	MSin                   []frontend.Variable // `gnark:",public"`
+	XATSin                 []frontend.Variable // `gnark:",public"`
+	TkXAPPin               []frontend.Variable // `gnark:",public"`
 	// TkCommit               [32]frontend.Variable // `gnark:",public"`
 
 	// authtag params
@@ -111,6 +280,13 @@ type Tls13Oracle struct {
 	ECB0      [16]frontend.Variable // `gnark:",public"`
 	ECBK      [16]frontend.Variable // `gnark:",public"`
 
+	// full authtag params, replace the authtag params if set
+	fullTag      bool
+	RecordHeader [5]frontend.Variable  // `gnark:",public"`
+	Ciphertext   []frontend.Variable   // `gnark:",public"`
+	Tag          [16]frontend.Variable // `gnark:",public"`
+	ChunkOffset  int                   // `gnark:",public"`
+
 	// record params
 	PlainChunks    []frontend.Variable
 	Iv             [12]frontend.Variable // `gnark:",public"`
@@ -123,13 +299,39 @@ type Tls13Oracle struct {
 	ValueEnd       int                   // `gnark:",public"`
 	Threshold      frontend.Variable     // `gnark:",public"`
 	SequenceNumber [8]frontend.Variable  // `gnark:",public"`
+
+	// private position params, replace the int positions of the record params if set
+	selectPositions      bool
+	Separator            []frontend.Variable // `gnark:",public"`
+	MaxValueLen          int                 // `gnark:",public"`
+	SelectSubstringStart frontend.Variable
+	SelectValueStart     frontend.Variable
+	SelectValueEnd       frontend.Variable
+
+	stages *utils.Stages
 }
 
+// aes128 is evaluated with the lookup based implementation
 func NewTls13Oracle(api frontend.API) Tls13Oracle {
-	return Tls13Oracle{api: api}
+	return NewTls13OracleWithAES(api, nil)
 }
 
-func (circuit *Tls13Oracle) SetKdcParams(IntermediateHashHSopad, MSin, XATSin, TkXAPPin [32]frontend.Variable, DHSin [64]frontend.Variable) {
+// aes128 is evaluated with the given implementation for tag and record, nil selects the lookup based aes128
+func NewTls13OracleWithAES(api frontend.API, aes aes128.AES) Tls13Oracle {
+	return Tls13Oracle{api: api, aes: aes, CipherSuite: record.TLS_AES_128_GCM_SHA256}
+}
+
+// counts the constraints of kdc, authtag, gcm, substring, str2int and policy, nil counts nothing
+func (circuit *Tls13Oracle) SetStages(stages *utils.Stages) {
+	circuit.stages = stages
+}
+
+// selects the key schedule hash and the record cipher
+func (circuit *Tls13Oracle) SetCipherSuite(suite uint16) {
+	circuit.CipherSuite = suite
+}
+
+func (circuit *Tls13Oracle) SetKdcParams(IntermediateHashHSopad, MSin, XATSin, TkXAPPin, DHSin []frontend.Variable) {
 	circuit.IntermediateHashHSopad = IntermediateHashHSopad
 	circuit.MSin = MSin
 	circuit.XATSin = XATSin
@@ -145,6 +347,15 @@ func (circuit *Tls13Oracle) SetAuthtagParams(ivCounter, zeros, ecb0, ecbk [16]fr
 	circuit.ECBK = ecbk
 }
 
+// switches to in-circuit ghash over the full record ciphertext, chunkOffset locates the record chunks in it
+func (circuit *Tls13Oracle) SetTagParams(recordHeader [5]frontend.Variable, ciphertext []frontend.Variable, tag [16]frontend.Variable, chunkOffset int) {
+	circuit.fullTag = true
+	circuit.RecordHeader = recordHeader
+	circuit.Ciphertext = ciphertext
+	circuit.Tag = tag
+	circuit.ChunkOffset = chunkOffset
+}
+
 func (circuit *Tls13Oracle) SetRecordParams(iv [12]frontend.Variable, plainChunks, cipherChunks, substring []frontend.Variable, chunkIndex, threshold frontend.Variable, substringStart, substringEnd, valueStart, valueEnd int, sequenceNumber [8]frontend.Variable) {
 	circuit.PlainChunks = plainChunks
 	circuit.Iv = iv
@@ -159,44 +370,215 @@ func (circuit *Tls13Oracle) SetRecordParams(iv [12]frontend.Variable, plainChunk
 	circuit.SequenceNumber = sequenceNumber
 }
 
-// Define declares the circuit's constraints
-func (circuit *Tls13Oracle) Assert() {
+// record params with private positions, the value follows substring||separator and is read from a window of maxValueLen bytes
+func (circuit *Tls13Oracle) SetRecordSelectParams(iv [12]frontend.Variable, plainChunks, cipherChunks, substring, separator []frontend.Variable, chunkIndex, threshold, substringStart, valueStart, valueEnd frontend.Variable, maxValueLen int, sequenceNumber [8]frontend.Variable) {
+	circuit.selectPositions = true
+	circuit.PlainChunks = plainChunks
+	circuit.Iv = iv
+	circuit.CipherChunks = cipherChunks
+	circuit.ChunkIndex = chunkIndex
+	circuit.Substring = substring
+	circuit.Separator = separator
+	circuit.Threshold = threshold
+	circuit.SelectSubstringStart = substringStart
+	circuit.SelectValueStart = valueStart
+	circuit.SelectValueEnd = valueEnd
+	circuit.MaxValueLen = maxValueLen
+	circuit.SequenceNumber = sequenceNumber
+}
 
-	// kdc verification
+// derives the server traffic key and iv of the cipher suite
+func (circuit *Tls13Oracle) deriveKeyIv() ([]frontend.Variable, [12]frontend.Variable, error) {
+
+	var iv [12]frontend.Variable
+
+	switch circuit.CipherSuite {
+	case record.TLS_AES_128_GCM_SHA256, record.TLS_CHACHA20_POLY1305_SHA256:
+		if len(circuit.DHSin) != 64 || len(circuit.IntermediateHashHSopad) != 32 || len(circuit.MSin) != 32 || len(circuit.XATSin) != 32 || len(circuit.TkXAPPin) != 32 {
+			return nil, iv, fmt.Errorf("origo: kdc params do not match the sha256 key schedule of %#04x", circuit.CipherSuite)
+		}
+		var dHSin [64]frontend.Variable
+		var opad, msIn, xatsIn, tkIn [32]frontend.Variable
+		copy(dHSin[:], circuit.DHSin)
+		copy(opad[:], circuit.IntermediateHashHSopad)
+		copy(msIn[:], circuit.MSin)
+		copy(xatsIn[:], circuit.XATSin)
+		copy(tkIn[:], circuit.TkXAPPin)
+
+		tls13_kdc := kdc.NewTls13Kdc(circuit.api)
+		tls13_kdc.SetParams(opad, msIn, xatsIn, tkIn, dHSin)
+		if circuit.CipherSuite == record.TLS_CHACHA20_POLY1305_SHA256 {
+			tls13_kdc.SetKeyLength(32)
+		}
+		tk, iv := tls13_kdc.DeriveKeyIv()
+		return tk, iv, nil
+
+	case record.TLS_AES_256_GCM_SHA384:
+		if len(circuit.DHSin) != 128 || len(circuit.IntermediateHashHSopad) != 64 || len(circuit.MSin) != 48 || len(circuit.XATSin) != 48 || len(circuit.TkXAPPin) != 48 {
+			return nil, iv, fmt.Errorf("origo: kdc params do not match TLS_AES_256_GCM_SHA384")
+		}
+		var dHSin [128]frontend.Variable
+		var opad [64]frontend.Variable
+		var msIn, xatsIn, tkIn [48]frontend.Variable
+		copy(dHSin[:], circuit.DHSin)
+		copy(opad[:], circuit.IntermediateHashHSopad)
+		copy(msIn[:], circuit.MSin)
+		copy(xatsIn[:], circuit.XATSin)
+		copy(tkIn[:], circuit.TkXAPPin)
+
+		tls13_kdc := kdc.NewTls13Kdc384(circuit.api)
+		tls13_kdc.SetParams(opad, msIn, xatsIn, tkIn, dHSin)
+		tk, iv := tls13_kdc.DeriveKeyIv()
+		return tk, iv, nil
+
+	default:
+		return nil, iv, fmt.Errorf("origo: unsupported cipher suite %#04x", circuit.CipherSuite)
+	}
+}
 
-	// derive key
-	tls13_kdc := kdc.NewTls13Kdc(circuit.api)
-	tls13_kdc.SetParams(
-		circuit.IntermediateHashHSopad,
-		circuit.MSin,
-		circuit.XATSin,
-		circuit.TkXAPPin,
-		circuit.DHSin,
-	)
-	tk := tls13_kdc.Derive()
+// aes round keys of the traffic key
+func (circuit *Tls13Oracle) expandKey(aes aes128.AES, tk []frontend.Variable) ([]frontend.Variable, error) {
+	switch len(tk) {
+	case 16:
+		var key [16]frontend.Variable
+		copy(key[:], tk)
+		expandedKey := aes.ExpandKey(key)
+		return expandedKey[:], nil
+	case 32:
+		var key [32]frontend.Variable
+		copy(key[:], tk)
+		aes := aes256.NewAES256(circuit.api)
+		expandedKey := aes.ExpandKey(key)
+		return expandedKey[:], nil
+	}
+	return nil, fmt.Errorf("origo: unsupported traffic key length %d", len(tk))
+}
 
-	// authtag verification
+// verifies the gcm tag of the record, in-circuit ghash over the record if the tag params are set
+func (circuit *Tls13Oracle) assertGCMTag(aes aes128.AES, tk []frontend.Variable, iv [12]frontend.Variable, expandedKey []frontend.Variable) error {
 
 	// init
-	tag := authtag.NewTls13AuthTag(circuit.api)
+	tag := authtag.NewTls13AuthTagWithAES(circuit.api, aes)
+	tag.SetExpandedKey(expandedKey)
+
+	if circuit.fullTag {
+		tag.SetRecordParams(tk, iv, circuit.SequenceNumber, circuit.RecordHeader, circuit.Ciphertext, circuit.Tag)
+
+		// verify tag
+		if err := tag.AssertGHash(); err != nil {
+			return err
+		}
+
+		// bind record chunks to the authenticated ciphertext, counter 1 is reserved for the tag
+		for i := range circuit.CipherChunks {
+			circuit.api.AssertIsEqual(circuit.CipherChunks[i], circuit.Ciphertext[circuit.ChunkOffset+i])
+		}
+		circuit.api.AssertIsEqual(circuit.ChunkIndex, circuit.ChunkOffset/16+2)
+		return nil
+	}
+
+	// tag counter block of the record, nonce of the derived iv and the sequence number with counter 1
+	gcm := aes128.NewGCM(circuit.api, nil)
+	j0 := gcm.GetIVTLS13(iv, 1, circuit.SequenceNumber)
+	for i := range j0 {
+		circuit.api.AssertIsEqual(circuit.IvCounter[i], j0[i])
+	}
+	tag.SetParams(tk, circuit.IvCounter, circuit.Zeros, circuit.ECB0, circuit.ECBK)
 
-	// type conversion
-	var tk16 [16]frontend.Variable
-	copy(tk16[:], tk)
-	tag.SetParams(tk16, circuit.IvCounter, circuit.Zeros, circuit.ECB0, circuit.ECBK)
+	// verify tag
+	return tag.Assert()
+}
+
+// verifies the poly1305 tag over the full record, the ecb tag params have no chacha20 counterpart
+func (circuit *Tls13Oracle) assertPoly1305(tk []frontend.Variable, iv [12]frontend.Variable) error {
+
+	if !circuit.fullTag {
+		return fmt.Errorf("origo: TLS_CHACHA20_POLY1305_SHA256 requires the record tag params")
+	}
+	if len(tk) != 32 {
+		return fmt.Errorf("origo: TLS_CHACHA20_POLY1305_SHA256 requires a 32 byte key, got %d", len(tk))
+	}
+	if circuit.ChunkOffset%64 != 0 {
+		return fmt.Errorf("origo: chunk offset %d is not at a chacha20 block boundary", circuit.ChunkOffset)
+	}
+	var key [32]frontend.Variable
+	copy(key[:], tk)
 
 	// verify tag
-	tag.Assert()
+	chacha := chacha20.NewChaCha20(circuit.api)
+	if err := chacha.AssertTag(key, iv, circuit.SequenceNumber, circuit.RecordHeader, circuit.Ciphertext, circuit.Tag); err != nil {
+		return err
+	}
+
+	// bind record chunks to the authenticated ciphertext, block counter 0 is reserved for the poly1305 key
+	for i := range circuit.CipherChunks {
+		circuit.api.AssertIsEqual(circuit.CipherChunks[i], circuit.Ciphertext[circuit.ChunkOffset+i])
+	}
+	circuit.api.AssertIsEqual(circuit.ChunkIndex, circuit.ChunkOffset/64+1)
+	return nil
+}
+
+// Define declares the circuit's constraints
+func (circuit *Tls13Oracle) Assert() error {
+
+	defer circuit.stages.End()
+
+	// kdc verification
+	circuit.stages.Begin("kdc")
+
+	// derive key and iv
+	tk, iv, err := circuit.deriveKeyIv()
+	if err != nil {
+		return err
+	}
+
+	// the public iv is bound to the handshake
+	for i := range iv {
+		circuit.api.AssertIsEqual(circuit.Iv[i], iv[i])
+	}
+
+	// one aes128 instance shared by tag and record
+	aes := circuit.aes
+	if aes == nil && circuit.CipherSuite == record.TLS_AES_128_GCM_SHA256 {
+		lookup := aes128lookup.NewAES128(circuit.api)
+		aes = &lookup
+	}
+
+	// authtag verification
+	circuit.stages.Begin("authtag")
+
+	// poly1305 tag, chacha20 has no key schedule to share
+	var expandedKey []frontend.Variable
+	if circuit.CipherSuite == record.TLS_CHACHA20_POLY1305_SHA256 {
+		if err := circuit.assertPoly1305(tk, iv); err != nil {
+			return err
+		}
+	} else {
+		// traffic key round keys shared by tag and record
+		expandedKey, err = circuit.expandKey(aes, tk)
+		if err != nil {
+			return err
+		}
+		if err := circuit.assertGCMTag(aes, tk, iv, expandedKey); err != nil {
+			return err
+		}
+	}
 
 	// policy-based data verification
+	if circuit.selectPositions {
+		return circuit.assertRecordSelect(aes, tk, iv, expandedKey)
+	}
 
 	// init
-	record := record.NewTls13Record(circuit.api)
+	record := record.NewTls13RecordWithAES(circuit.api, aes)
+	record.SetCipherSuite(circuit.CipherSuite)
+	record.SetStages(circuit.stages)
+	record.SetExpandedKey(expandedKey)
 
 	// insert data
 	record.SetParams(
-		tk16,
-		circuit.Iv,
+		tk,
+		iv,
 		circuit.PlainChunks,
 		circuit.CipherChunks,
 		circuit.Substring,
@@ -210,5 +592,35 @@ func (circuit *Tls13Oracle) Assert() {
 	)
 
 	// verify
-	record.Assert()
+	return record.Assert()
+}
+
+// verifies the record chunks and the value at the private positions
+func (circuit *Tls13Oracle) assertRecordSelect(aes aes128.AES, tk []frontend.Variable, iv [12]frontend.Variable, expandedKey []frontend.Variable) error {
+
+	// init
+	record := record.NewTls13RecordSelectWithAES(circuit.api, aes)
+	record.SetCipherSuite(circuit.CipherSuite)
+	record.SetStages(circuit.stages)
+	record.SetExpandedKey(expandedKey)
+
+	// insert data
+	record.SetParams(
+		tk,
+		iv,
+		circuit.PlainChunks,
+		circuit.CipherChunks,
+		circuit.Substring,
+		circuit.ChunkIndex,
+		circuit.Threshold,
+		circuit.SelectSubstringStart,
+		circuit.SelectValueStart,
+		circuit.SelectValueEnd,
+		circuit.MaxValueLen,
+		circuit.SequenceNumber,
+	)
+	record.SetSeparator(circuit.Separator)
+
+	// verify
+	return record.Assert()
 }
@@ -0,0 +1,512 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package origo
+
+import (
+	record "circuits/record"
+	utils "circuits/utils"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// json parameter schema as logged by the origo proxy
+type FinalParams struct {
+	CATSin                 string `json:"CATSin"`
+	ECB0                   string `json:"ECB0"`
+	ECBK                   string `json:"ECBK"`
+	MSin                   string `json:"MSin"`
+	SATSin                 string `json:"SATSin"`
+	ChunkIndex             int    `json:"chunk_index,string"`
+	CipherChunks           string `json:"cipher_chunks"`
+	DHSin                  string `json:"dHSin"`
+	HashKeyCapp            string `json:"hashKeyCapp"`
+	HashKeySapp            string `json:"hashKeySapp"`
+	IntermediateHashHSopad string `json:"intermediateHashHSopad"`
+	IvCapp                 string `json:"ivCapp"`
+	IvSapp                 string `json:"ivSapp"`
+	NumberChunks           int    `json:"number_chunks,string"`
+	PlainChunks            string `json:"plain_chunks"`
+	SizeAreaOfInterest     int    `json:"size_area_of_interest,string"`
+	SizeValue              int    `json:"size_value,string"`
+	Substring              string `json:"substring"`
+	SubstringEnd           int    `json:"substring_end,string"`
+	SubstringStart         int    `json:"substring_start,string"`
+	SubstringStartIdx      int    `json:"substring_start_idx,string"`
+	TkCAPPin               string `json:"tkCAPPin"`
+	TkSAPPin               string `json:"tkSAPPin"`
+	ValueEnd               int    `json:"value_end,string"`
+	ValueStart             int    `json:"value_start,string"`
+	SequenceNumber         string `json:"sequence_number"`
+	RecordHeader           string `json:"record_header,omitempty"`
+	Ciphertext             string `json:"ciphertext,omitempty"`
+	Tag                    string `json:"tag,omitempty"`
+	CipherSuite            uint16 `json:"cipher_suite,string,omitempty"`
+}
+
+// parses the json parameter schema
+func ParseFinalParams(raw []byte) (FinalParams, error) {
+	var data FinalParams
+	err := json.Unmarshal(raw, &data)
+	return data, err
+}
+
+// hex encoded gcm counter block J0 of a tls 1.3 record, the per record nonce iv xor the left padded
+// sequence number followed by counter 1
+func tagCounterBlock(iv, sequenceNumber string) (string, error) {
+	nonce, err := hex.DecodeString(iv)
+	if err != nil || len(nonce) != 12 {
+		return "", fmt.Errorf("ivSapp: expected 12 hex encoded bytes")
+	}
+	seq, err := hex.DecodeString(sequenceNumber)
+	if err != nil || len(seq) != 8 {
+		return "", fmt.Errorf("sequence_number: expected 8 hex encoded bytes")
+	}
+	for i := range seq {
+		nonce[4+i] ^= seq[i]
+	}
+	return hex.EncodeToString(append(nonce, 0, 0, 0, 1)), nil
+}
+
+// digits of the value window of the oracle circuit, values of up to DefaultMaxValueLen digits share one circuit
+const DefaultMaxValueLen = 16
+
+// returns the circuit definition and the witness assignment of the oracle circuit for the given parameters
+// the positions are assigned privately, the separator is the plaintext between substring and value
+func NewTls13OracleWrapperFromParams(data FinalParams, threshold int) (Tls13OracleWrapper, Tls13OracleWrapper, error) {
+
+	// server side traffic parameters
+	iv := data.IvSapp
+	zeros := "00000000000000000000000000000000"
+
+	// tag counter block of the record, ECB0 is its encryption
+	ivCounter, err := tagCounterBlock(iv, data.SequenceNumber)
+	if err != nil {
+		return Tls13OracleWrapper{}, Tls13OracleWrapper{}, err
+	}
+
+	// add padding out of circuit
+	dHSSlice, err := hex.DecodeString(data.DHSin)
+	if err != nil {
+		return Tls13OracleWrapper{}, Tls13OracleWrapper{}, fmt.Errorf("dHSin: %w", err)
+	}
+	pad := utils.PadSha256(96)
+	dHSinPadded := make([]byte, 32+len(pad))
+	copy(dHSinPadded, dHSSlice)
+	copy(dHSinPadded[32:], pad)
+	newdHSin := hex.EncodeToString(dHSinPadded)
+
+	// decode all hex encoded parameters
+	fields := []struct {
+		name  string
+		value string
+		size  int
+	}{
+		{"intermediateHashHSopad", data.IntermediateHashHSopad, 32},
+		{"dHSin", newdHSin, 64},
+		{"MSin", data.MSin, 32},
+		{"SATSin", data.SATSin, 32},
+		{"tkSAPPin", data.TkSAPPin, 32},
+		{"ivCounter", ivCounter, 16},
+		{"zeros", zeros, 16},
+		{"ECB0", data.ECB0, 16},
+		{"ECBK", data.ECBK, 16},
+		{"ivSapp", iv, 12},
+		{"sequence_number", data.SequenceNumber, 8},
+	}
+	decoded := make([][]int, len(fields))
+	for i, f := range fields {
+		byteSlice, err := hex.DecodeString(f.value)
+		if err != nil {
+			return Tls13OracleWrapper{}, Tls13OracleWrapper{}, fmt.Errorf("%s: %w", f.name, err)
+		}
+		if len(byteSlice) != f.size {
+			return Tls13OracleWrapper{}, Tls13OracleWrapper{}, fmt.Errorf("%s: expected %d bytes, got %d", f.name, f.size, len(byteSlice))
+		}
+		decoded[i] = utils.StrToIntSlice(f.value, true)
+	}
+
+	cipherSlice, err := hex.DecodeString(data.CipherChunks)
+	if err != nil {
+		return Tls13OracleWrapper{}, Tls13OracleWrapper{}, fmt.Errorf("cipher_chunks: %w", err)
+	}
+	plainSlice, err := hex.DecodeString(data.PlainChunks)
+	if err != nil {
+		return Tls13OracleWrapper{}, Tls13OracleWrapper{}, fmt.Errorf("plain_chunks: %w", err)
+	}
+	if len(cipherSlice) != len(plainSlice) {
+		return Tls13OracleWrapper{}, Tls13OracleWrapper{}, fmt.Errorf("cipher_chunks and plain_chunks differ in length")
+	}
+	if data.SubstringStart < 0 || data.SubstringEnd > len(plainSlice) || data.SubstringEnd-data.SubstringStart != len(data.Substring) {
+		return Tls13OracleWrapper{}, Tls13OracleWrapper{}, fmt.Errorf("substring positions out of range")
+	}
+	// the byte after the value terminates it and must be part of the chunks
+	if data.ValueStart < data.SubstringEnd || data.ValueEnd >= len(plainSlice) || data.ValueStart >= data.ValueEnd {
+		return Tls13OracleWrapper{}, Tls13OracleWrapper{}, fmt.Errorf("value positions out of range")
+	}
+	if data.ValueEnd-data.ValueStart > DefaultMaxValueLen {
+		return Tls13OracleWrapper{}, Tls13OracleWrapper{}, fmt.Errorf("value of %d digits exceeds %d digits", data.ValueEnd-data.ValueStart, DefaultMaxValueLen)
+	}
+
+	// witness definition record
+	chipherChunksAssign := utils.StrToIntSlice(data.CipherChunks, true)
+	plainChunksAssign := utils.StrToIntSlice(data.PlainChunks, true)
+	substringAssign := utils.StrToIntSlice(data.Substring, false)
+	separator := plainChunksAssign[data.SubstringEnd:data.ValueStart]
+
+	// witness values preparation
+	assignment := Tls13OracleWrapper{
+		PlainChunks:    make([]frontend.Variable, len(plainSlice)),
+		SubstringStart: data.SubstringStart,
+		ValueStart:     data.ValueStart,
+		ValueEnd:       data.ValueEnd,
+		CipherChunks:   make([]frontend.Variable, len(cipherSlice)),
+		ChunkIndex:     data.ChunkIndex,
+		Substring:      make([]frontend.Variable, len(data.Substring)),
+		Separator:      toVariables(separator),
+		MaxValueLen:    DefaultMaxValueLen,
+		Threshold:      threshold,
+	}
+
+	// kdc assign
+	for i := range assignment.IntermediateHashHSopad {
+		assignment.IntermediateHashHSopad[i] = decoded[0][i]
+	}
+	for i := range assignment.DHSin {
+		assignment.DHSin[i] = decoded[1][i]
+	}
+	for i := range assignment.MSin {
+		assignment.MSin[i] = decoded[2][i]
+	}
+	for i := range assignment.SATSin {
+		assignment.SATSin[i] = decoded[3][i]
+	}
+	for i := range assignment.TkSAPPin {
+		assignment.TkSAPPin[i] = decoded[4][i]
+	}
+	// authtag assign
+	for i := range assignment.IvCounter {
+		assignment.IvCounter[i] = decoded[5][i]
+	}
+	for i := range assignment.Zeros {
+		assignment.Zeros[i] = decoded[6][i]
+	}
+	for i := range assignment.ECB0 {
+		assignment.ECB0[i] = decoded[7][i]
+	}
+	for i := range assignment.ECBK {
+		assignment.ECBK[i] = decoded[8][i]
+	}
+	// record assign
+	for i := range assignment.Iv {
+		assignment.Iv[i] = decoded[9][i]
+	}
+	for i := range assignment.SequenceNumber {
+		assignment.SequenceNumber[i] = decoded[10][i]
+	}
+	for i := range assignment.PlainChunks {
+		assignment.PlainChunks[i] = plainChunksAssign[i]
+	}
+	for i := range assignment.CipherChunks {
+		assignment.CipherChunks[i] = chipherChunksAssign[i]
+	}
+	for i := range assignment.Substring {
+		assignment.Substring[i] = substringAssign[i]
+	}
+
+	// circuit definition, only the slice lengths fix the constraint system
+	circuit := Tls13OracleWrapper{
+		PlainChunks:  make([]frontend.Variable, len(plainSlice)),
+		CipherChunks: make([]frontend.Variable, len(cipherSlice)),
+		Substring:    make([]frontend.Variable, len(data.Substring)),
+		Separator:    make([]frontend.Variable, len(separator)),
+		MaxValueLen:  DefaultMaxValueLen,
+	}
+
+	return circuit, assignment, nil
+}
+
+// decodes a hex parameter of fixed size into byte values
+func decodeHex(name, value string, size int) ([]int, error) {
+	byteSlice, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if size >= 0 && len(byteSlice) != size {
+		return nil, fmt.Errorf("%s: expected %d bytes, got %d", name, size, len(byteSlice))
+	}
+	out := make([]int, len(byteSlice))
+	for i, b := range byteSlice {
+		out[i] = int(b)
+	}
+	return out, nil
+}
+
+// returns the circuit definition and the witness assignment of the oracle circuit with in-circuit ghash
+func NewTls13OracleGHashWrapperFromParams(data FinalParams, threshold int) (Tls13OracleGHashWrapper, Tls13OracleGHashWrapper, error) {
+
+	// add padding out of circuit
+	dHSin, err := decodeHex("dHSin", data.DHSin, 32)
+	if err != nil {
+		return Tls13OracleGHashWrapper{}, Tls13OracleGHashWrapper{}, err
+	}
+	for _, b := range utils.PadSha256(96) {
+		dHSin = append(dHSin, int(b))
+	}
+
+	fields := []struct {
+		name  string
+		value string
+		size  int
+	}{
+		{"intermediateHashHSopad", data.IntermediateHashHSopad, 32},
+		{"MSin", data.MSin, 32},
+		{"SATSin", data.SATSin, 32},
+		{"tkSAPPin", data.TkSAPPin, 32},
+		{"ivSapp", data.IvSapp, 12},
+		{"sequence_number", data.SequenceNumber, 8},
+		{"record_header", data.RecordHeader, 5},
+		{"tag", data.Tag, 16},
+		{"ciphertext", data.Ciphertext, -1},
+		{"plain_chunks", data.PlainChunks, -1},
+	}
+	decoded := make([][]int, len(fields))
+	for i, f := range fields {
+		decoded[i], err = decodeHex(f.name, f.value, f.size)
+		if err != nil {
+			return Tls13OracleGHashWrapper{}, Tls13OracleGHashWrapper{}, err
+		}
+	}
+	ciphertext, plainChunks := decoded[8], decoded[9]
+
+	// record chunks are located in the ciphertext by their counter, counter 1 is reserved for the tag
+	chunkOffset := (data.ChunkIndex - 2) * 16
+	if chunkOffset < 0 || chunkOffset+len(plainChunks) > len(ciphertext) {
+		return Tls13OracleGHashWrapper{}, Tls13OracleGHashWrapper{}, fmt.Errorf("plain_chunks out of ciphertext range")
+	}
+	if data.SubstringStart < 0 || data.SubstringEnd > len(plainChunks) || data.SubstringEnd-data.SubstringStart != len(data.Substring) {
+		return Tls13OracleGHashWrapper{}, Tls13OracleGHashWrapper{}, fmt.Errorf("substring positions out of range")
+	}
+	if data.ValueStart < 0 || data.ValueEnd > len(plainChunks) || data.ValueStart > data.ValueEnd {
+		return Tls13OracleGHashWrapper{}, Tls13OracleGHashWrapper{}, fmt.Errorf("value positions out of range")
+	}
+
+	// witness values preparation
+	assignment := Tls13OracleGHashWrapper{
+		Ciphertext:     make([]frontend.Variable, len(ciphertext)),
+		ChunkOffset:    chunkOffset,
+		PlainChunks:    make([]frontend.Variable, len(plainChunks)),
+		ChunkIndex:     data.ChunkIndex,
+		Substring:      make([]frontend.Variable, len(data.Substring)),
+		SubstringStart: data.SubstringStart,
+		SubstringEnd:   data.SubstringEnd,
+		ValueStart:     data.ValueStart,
+		ValueEnd:       data.ValueEnd,
+		Threshold:      threshold,
+	}
+
+	// kdc assign
+	for i := range assignment.DHSin {
+		assignment.DHSin[i] = dHSin[i]
+	}
+	for i := range assignment.IntermediateHashHSopad {
+		assignment.IntermediateHashHSopad[i] = decoded[0][i]
+	}
+	for i := range assignment.MSin {
+		assignment.MSin[i] = decoded[1][i]
+	}
+	for i := range assignment.SATSin {
+		assignment.SATSin[i] = decoded[2][i]
+	}
+	for i := range assignment.TkSAPPin {
+		assignment.TkSAPPin[i] = decoded[3][i]
+	}
+	// authtag assign
+	for i := range assignment.RecordHeader {
+		assignment.RecordHeader[i] = decoded[6][i]
+	}
+	for i := range assignment.Tag {
+		assignment.Tag[i] = decoded[7][i]
+	}
+	for i := range assignment.Ciphertext {
+		assignment.Ciphertext[i] = ciphertext[i]
+	}
+	// record assign
+	for i := range assignment.Iv {
+		assignment.Iv[i] = decoded[4][i]
+	}
+	for i := range assignment.SequenceNumber {
+		assignment.SequenceNumber[i] = decoded[5][i]
+	}
+	for i := range assignment.PlainChunks {
+		assignment.PlainChunks[i] = plainChunks[i]
+	}
+	for i := range assignment.Substring {
+		assignment.Substring[i] = int(data.Substring[i])
+	}
+
+	// circuit definition, slice lengths and positions fix the constraint system
+	circuit := Tls13OracleGHashWrapper{
+		Ciphertext:     make([]frontend.Variable, len(ciphertext)),
+		ChunkOffset:    chunkOffset,
+		PlainChunks:    make([]frontend.Variable, len(plainChunks)),
+		Substring:      make([]frontend.Variable, len(data.Substring)),
+		SubstringStart: data.SubstringStart,
+		SubstringEnd:   data.SubstringEnd,
+		ValueStart:     data.ValueStart,
+		ValueEnd:       data.ValueEnd,
+	}
+
+	return circuit, assignment, nil
+}
+
+// returns the circuit definition and the witness assignment of the oracle circuit for the negotiated cipher suite
+// a missing cipher suite defaults to TLS_AES_128_GCM_SHA256
+func NewTls13OracleSuiteWrapperFromParams(data FinalParams, threshold int) (Tls13OracleSuiteWrapper, Tls13OracleSuiteWrapper, error) {
+
+	suite := data.CipherSuite
+	if suite == 0 {
+		suite = record.TLS_AES_128_GCM_SHA256
+	}
+
+	// hash output and chaining value sizes of the key schedule
+	var hashSize, stateSize int
+	var pad []byte
+	switch suite {
+	case record.TLS_AES_128_GCM_SHA256, record.TLS_CHACHA20_POLY1305_SHA256:
+		hashSize, stateSize, pad = 32, 32, utils.PadSha256(64+32)
+	case record.TLS_AES_256_GCM_SHA384:
+		hashSize, stateSize, pad = 48, 64, utils.PadSha512(128+48)
+	default:
+		return Tls13OracleSuiteWrapper{}, Tls13OracleSuiteWrapper{}, fmt.Errorf("cipher_suite: unsupported %#04x", suite)
+	}
+
+	// add padding out of circuit
+	dHSin, err := decodeHex("dHSin", data.DHSin, hashSize)
+	if err != nil {
+		return Tls13OracleSuiteWrapper{}, Tls13OracleSuiteWrapper{}, err
+	}
+	for _, b := range pad {
+		dHSin = append(dHSin, int(b))
+	}
+
+	fields := []struct {
+		name  string
+		value string
+		size  int
+	}{
+		{"intermediateHashHSopad", data.IntermediateHashHSopad, stateSize},
+		{"MSin", data.MSin, hashSize},
+		{"SATSin", data.SATSin, hashSize},
+		{"tkSAPPin", data.TkSAPPin, hashSize},
+		{"ivSapp", data.IvSapp, 12},
+		{"sequence_number", data.SequenceNumber, 8},
+		{"record_header", data.RecordHeader, 5},
+		{"tag", data.Tag, 16},
+		{"ciphertext", data.Ciphertext, -1},
+		{"plain_chunks", data.PlainChunks, -1},
+	}
+	decoded := make([][]int, len(fields))
+	for i, f := range fields {
+		decoded[i], err = decodeHex(f.name, f.value, f.size)
+		if err != nil {
+			return Tls13OracleSuiteWrapper{}, Tls13OracleSuiteWrapper{}, err
+		}
+	}
+	ciphertext, plainChunks := decoded[8], decoded[9]
+
+	// record chunks are located in the ciphertext by their counter, gcm counter 1 is reserved for the tag
+	// and chacha20 block 0 for the poly1305 key
+	chunkOffset := (data.ChunkIndex - 2) * 16
+	if suite == record.TLS_CHACHA20_POLY1305_SHA256 {
+		chunkOffset = (data.ChunkIndex - 1) * 64
+	}
+	if chunkOffset < 0 || chunkOffset+len(plainChunks) > len(ciphertext) {
+		return Tls13OracleSuiteWrapper{}, Tls13OracleSuiteWrapper{}, fmt.Errorf("plain_chunks out of ciphertext range")
+	}
+	if data.SubstringStart < 0 || data.SubstringEnd > len(plainChunks) || data.SubstringEnd-data.SubstringStart != len(data.Substring) {
+		return Tls13OracleSuiteWrapper{}, Tls13OracleSuiteWrapper{}, fmt.Errorf("substring positions out of range")
+	}
+	if data.ValueStart < 0 || data.ValueEnd > len(plainChunks) || data.ValueStart > data.ValueEnd {
+		return Tls13OracleSuiteWrapper{}, Tls13OracleSuiteWrapper{}, fmt.Errorf("value positions out of range")
+	}
+
+	// witness values preparation
+	assignment := Tls13OracleSuiteWrapper{
+		CipherSuite:            suite,
+		DHSin:                  toVariables(dHSin),
+		IntermediateHashHSopad: toVariables(decoded[0]),
+		MSin:                   toVariables(decoded[1]),
+		SATSin:                 toVariables(decoded[2]),
+		TkSAPPin:               toVariables(decoded[3]),
+		Ciphertext:             toVariables(ciphertext),
+		ChunkOffset:            chunkOffset,
+		PlainChunks:            toVariables(plainChunks),
+		ChunkIndex:             data.ChunkIndex,
+		Substring:              make([]frontend.Variable, len(data.Substring)),
+		SubstringStart:         data.SubstringStart,
+		SubstringEnd:           data.SubstringEnd,
+		ValueStart:             data.ValueStart,
+		ValueEnd:               data.ValueEnd,
+		Threshold:              threshold,
+	}
+	for i := range assignment.RecordHeader {
+		assignment.RecordHeader[i] = decoded[6][i]
+	}
+	for i := range assignment.Tag {
+		assignment.Tag[i] = decoded[7][i]
+	}
+	for i := range assignment.Iv {
+		assignment.Iv[i] = decoded[4][i]
+	}
+	for i := range assignment.SequenceNumber {
+		assignment.SequenceNumber[i] = decoded[5][i]
+	}
+	for i := range assignment.Substring {
+		assignment.Substring[i] = int(data.Substring[i])
+	}
+
+	// circuit definition, slice lengths and positions fix the constraint system
+	circuit := Tls13OracleSuiteWrapper{
+		CipherSuite:            suite,
+		DHSin:                  make([]frontend.Variable, len(dHSin)),
+		IntermediateHashHSopad: make([]frontend.Variable, stateSize),
+		MSin:                   make([]frontend.Variable, hashSize),
+		SATSin:                 make([]frontend.Variable, hashSize),
+		TkSAPPin:               make([]frontend.Variable, hashSize),
+		Ciphertext:             make([]frontend.Variable, len(ciphertext)),
+		ChunkOffset:            chunkOffset,
+		PlainChunks:            make([]frontend.Variable, len(plainChunks)),
+		Substring:              make([]frontend.Variable, len(data.Substring)),
+		SubstringStart:         data.SubstringStart,
+		SubstringEnd:           data.SubstringEnd,
+		ValueStart:             data.ValueStart,
+		ValueEnd:               data.ValueEnd,
+	}
+
+	return circuit, assignment, nil
+}
+
+func toVariables(in []int) []frontend.Variable {
+	out := make([]frontend.Variable, len(in))
+	for i := range in {
+		out[i] = in[i]
+	}
+	return out
+}
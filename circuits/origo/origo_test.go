@@ -2,9 +2,8 @@ package origo
 
 import (
 	utils "circuits/utils"
-	"encoding/hex"
-	"encoding/json"
-	"strings"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/consensys/gnark-crypto/ecc"
@@ -13,35 +12,6 @@ import (
 	"github.com/consensys/gnark/test"
 )
 
-type FinalParams struct {
-	CATSin                 string `json:"CATSin"`
-	ECB0                   string `json:"ECB0"`
-	ECBK                   string `json:"ECBK"`
-	MSin                   string `json:"MSin"`
-	SATSin                 string `json:"SATSin"`
-	ChunkIndex             int    `json:"chunk_index,string"`
-	CipherChunks           string `json:"cipher_chunks"`
-	DHSin                  string `json:"dHSin"`
-	HashKeyCapp            string `json:"hashKeyCapp"`
-	HashKeySapp            string `json:"hashKeySapp"`
-	IntermediateHashHSopad string `json:"intermediateHashHSopad"`
-	IvCapp                 string `json:"ivCapp"`
-	IvSapp                 string `json:"ivSapp"`
-	NumberChunks           int    `json:"number_chunks,string"`
-	PlainChunks            string `json:"plain_chunks"`
-	SizeAreaOfInterest     int    `json:"size_area_of_interest,string"`
-	SizeValue              int    `json:"size_value,string"`
-	Substring              string `json:"substring"`
-	SubstringEnd           int    `json:"substring_end,string"`
-	SubstringStart         int    `json:"substring_start,string"`
-	SubstringStartIdx      int    `json:"substring_start_idx,string"`
-	TkCAPPin               string `json:"tkCAPPin"`
-	TkSAPPin               string `json:"tkSAPPin"`
-	ValueEnd               int    `json:"value_end,string"`
-	ValueStart             int    `json:"value_start,string"`
-	SequenceNumber         string `json:"sequence_number"`
-}
-
 const finalParamsStr = `{
 	"CATSin": "4d09468728220770fbac42bd52811a3f9209787d04f410ae006590e7d1c37ced",
 	"ECB0": "7a3da051a3a1976df16e6c201e78f67d",
@@ -100,7 +70,7 @@ const finalParamsStrPayPal = `{
 
 const finalParamsStrPayPalTest = `{
     "CATSin": "1e7d18d3fabb7f94ebebd9a626047ba74660423cbb039b14ba7e0f28943a3ba8",
-    "ECB0": "0656c3ffc0dfc88e748f91b265f02aa1",
+    "ECB0": "e1a3f1a397ecc41c6ea77b8ffb2f9d7d",
     "ECBK": "e22da555fd87c58a50c206501693c446",
     "MSin": "465a8f4e321881c53697568ec08b4dd68d4805dd49f57ae401ffa7a783eaeab3",
     "SATSin": "2af5e21c5aace4b244b52cc2740e8c8cff1beb6806a67fe19b0561467b607e02",
@@ -130,188 +100,17 @@ const finalParamsStrPayPalTest = `{
 // Common setup function for both tests
 func setupTls13OracleWrapperWrapper() (Tls13OracleWrapper, Tls13OracleWrapper) {
 
-	var data FinalParams
-	err := json.Unmarshal([]byte(finalParamsStrPayPalTest), &data)
+	data, err := ParseFinalParams([]byte(finalParamsStrPayPalTest))
 	if err != nil {
 		panic(err)
 	}
 
-	intermediateHashHSopad := data.IntermediateHashHSopad
-	dHSin := data.DHSin
-	MSin := data.MSin
-	SATSin := data.SATSin
-	tkSAPPin := data.TkSAPPin
-
-	iv := data.IvSapp                           // Assuming the hardcoded 'iv' corresponds to 'ivSapp' from the JSON. / Server side
-	zeros := "00000000000000000000000000000000" // This seems to be a constant string not from the JSON.
-	ecb0 := data.ECB0
-	ecbk := data.ECBK
-	cipherChunks := data.CipherChunks
-	plainChunks := data.PlainChunks
-	chunkIndex := data.ChunkIndex
-	substring := data.Substring
-	substringStart := data.SubstringStart
-	substringEnd := data.SubstringEnd
-	valueStart := data.ValueStart
-	valueEnd := data.ValueEnd
-	sequenceNumber := data.SequenceNumber
-
 	// FIX MANUALLY
 	threshold := 38001
 
-	// add counter to iv bytes
-	// Value of interest in first record - 1
-	// Value of interest in second record - 2
-	var sb strings.Builder
-	for i := 0; i < len(iv); i++ {
-		sb.WriteString(string(iv[i]))
-	}
-	for i := 0; i < 7; i++ {
-		sb.WriteString("0")
-	}
-	sb.WriteString("2")
-	ivCounter := sb.String()
-
-	byteSlice, _ := hex.DecodeString(sequenceNumber)
-	sequenceNumberByteLen := len(byteSlice)
-
-	// kdc to bytes
-	byteSlice, _ = hex.DecodeString(intermediateHashHSopad)
-	intermediateHashHSopadByteLen := len(byteSlice)
-	dHSSlice, _ := hex.DecodeString(dHSin)
-	dHSinByteLen := len(dHSSlice)
-	byteSlice, _ = hex.DecodeString(MSin)
-	MSinByteLen := len(byteSlice)
-	byteSlice, _ = hex.DecodeString(SATSin)
-	SATSinByteLen := len(byteSlice)
-	byteSlice, _ = hex.DecodeString(tkSAPPin)
-	tkSAPPinByteLen := len(byteSlice)
-	// authtag to bytes
-	byteSlice, _ = hex.DecodeString(ivCounter)
-	ivCounterByteLen := len(byteSlice)
-	byteSlice, _ = hex.DecodeString(zeros)
-	zerosByteLen := len(byteSlice)
-	byteSlice, _ = hex.DecodeString(ecb0)
-	ecb0ByteLen := len(byteSlice)
-	byteSlice, _ = hex.DecodeString(ecbk)
-	ecbkByteLen := len(byteSlice)
-	// record to bytes
-	byteSlice, _ = hex.DecodeString(iv)
-	ivByteLen := len(byteSlice)
-	byteSlice, _ = hex.DecodeString(cipherChunks)
-	chipherChunksByteLen := len(byteSlice)
-	byteSlice, _ = hex.DecodeString(plainChunks)
-	plainChunksByteLen := len(byteSlice)
-	substringByteLen := len(substring)
-
-	// add padding out of circuit
-	pad := utils.PadSha256(96)
-	dHSinPadded := make([]byte, 32+len(pad))
-	copy(dHSinPadded, dHSSlice)
-	copy(dHSinPadded[32:], pad)
-	newdHSin := hex.EncodeToString(dHSinPadded)
-	dHSinByteLen += 32
-
-	// witness definition kdc
-	intermediateHashHSopadAssign := utils.StrToIntSlice(intermediateHashHSopad, true)
-	dHSinAssign := utils.StrToIntSlice(newdHSin, true)
-	MSinAssign := utils.StrToIntSlice(MSin, true)
-	SATSinAssign := utils.StrToIntSlice(SATSin, true)
-	tkSAPPinAssign := utils.StrToIntSlice(tkSAPPin, true)
-	// witness definition authtag
-	ivCounterAssign := utils.StrToIntSlice(ivCounter, true)
-	zerosAssign := utils.StrToIntSlice(zeros, true)
-	ecb0Assign := utils.StrToIntSlice(ecb0, true)
-	ecbkAssign := utils.StrToIntSlice(ecbk, true)
-	// witness definition record
-	ivAssign := utils.StrToIntSlice(iv, true)
-	chipherChunksAssign := utils.StrToIntSlice(cipherChunks, true)
-	plainChunksAssign := utils.StrToIntSlice(plainChunks, true)
-	substringAssign := utils.StrToIntSlice(substring, false)
-	sequenceNumberAssign := utils.StrToIntSlice(sequenceNumber, true)
-
-	// witness values preparation
-	assignment := Tls13OracleWrapper{
-		// kdc params
-		IntermediateHashHSopad: [32]frontend.Variable{},
-		DHSin:                  [64]frontend.Variable{},
-		MSin:                   [32]frontend.Variable{},
-		SATSin:                 [32]frontend.Variable{},
-		TkSAPPin:               [32]frontend.Variable{},
-		// authtag params
-		IvCounter: [16]frontend.Variable{},
-		Zeros:     [16]frontend.Variable{},
-		ECB0:      [16]frontend.Variable{},
-		ECBK:      [16]frontend.Variable{},
-		// record pararms
-		PlainChunks:    make([]frontend.Variable, plainChunksByteLen),
-		Iv:             [12]frontend.Variable{},
-		CipherChunks:   make([]frontend.Variable, chipherChunksByteLen),
-		ChunkIndex:     chunkIndex,
-		Substring:      make([]frontend.Variable, substringByteLen),
-		SubstringStart: substringStart,
-		SubstringEnd:   substringEnd,
-		ValueStart:     valueStart,
-		ValueEnd:       valueEnd,
-		Threshold:      threshold,
-		SequenceNumber: [8]frontend.Variable{},
-	}
-
-	// kdc assign
-	for i := 0; i < intermediateHashHSopadByteLen; i++ {
-		assignment.IntermediateHashHSopad[i] = intermediateHashHSopadAssign[i]
-	}
-	for i := 0; i < dHSinByteLen; i++ {
-		assignment.DHSin[i] = dHSinAssign[i]
-	}
-	for i := 0; i < MSinByteLen; i++ {
-		assignment.MSin[i] = MSinAssign[i]
-	}
-	for i := 0; i < SATSinByteLen; i++ {
-		assignment.SATSin[i] = SATSinAssign[i]
-	}
-	for i := 0; i < tkSAPPinByteLen; i++ {
-		assignment.TkSAPPin[i] = tkSAPPinAssign[i]
-	}
-	// authtag assign
-	for i := 0; i < ivCounterByteLen; i++ {
-		assignment.IvCounter[i] = ivCounterAssign[i]
-	}
-	for i := 0; i < zerosByteLen; i++ {
-		assignment.Zeros[i] = zerosAssign[i]
-	}
-	for i := 0; i < ecbkByteLen; i++ {
-		assignment.ECBK[i] = ecbkAssign[i]
-	}
-	for i := 0; i < ecb0ByteLen; i++ {
-		assignment.ECB0[i] = ecb0Assign[i]
-	}
-	// record assign
-	for i := 0; i < plainChunksByteLen; i++ {
-		assignment.PlainChunks[i] = plainChunksAssign[i]
-	}
-	for i := 0; i < ivByteLen; i++ {
-		assignment.Iv[i] = ivAssign[i]
-	}
-	for i := 0; i < chipherChunksByteLen; i++ {
-		assignment.CipherChunks[i] = chipherChunksAssign[i]
-	}
-	for i := 0; i < substringByteLen; i++ {
-		assignment.Substring[i] = substringAssign[i]
-	}
-	for i := 0; i < sequenceNumberByteLen; i++ {
-		assignment.SequenceNumber[i] = sequenceNumberAssign[i]
-	}
-
-	// var circuit kdcServerKey
-	circuit := Tls13OracleWrapper{
-		PlainChunks:    make([]frontend.Variable, plainChunksByteLen),
-		CipherChunks:   make([]frontend.Variable, chipherChunksByteLen),
-		Substring:      make([]frontend.Variable, substringByteLen),
-		SubstringStart: substringStart,
-		SubstringEnd:   substringEnd,
-		ValueStart:     valueStart,
-		ValueEnd:       valueEnd,
+	circuit, assignment, err := NewTls13OracleWrapperFromParams(data, threshold)
+	if err != nil {
+		panic(err)
 	}
 
 	return circuit, assignment
@@ -335,14 +134,61 @@ func TestRecordProving(t *testing.T) {
 	assert.ProverSucceeded(&circuit, &assignment)
 }
 
+// Test for out of range private inputs
+func TestTls13OracleRejectsNonBytes(t *testing.T) {
+	circuit, assignment := setupTls13OracleWrapperWrapper()
+
+	dhs := assignment
+	dhs.DHSin[0] = dhs.DHSin[0].(int) + 256
+	if err := test.IsSolved(&circuit, &dhs, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected out of range DHSin to fail")
+	}
+
+	plain := assignment
+	plain.PlainChunks = append([]frontend.Variable{}, assignment.PlainChunks...)
+	plain.PlainChunks[0] = plain.PlainChunks[0].(int) + 256
+	if err := test.IsSolved(&circuit, &plain, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected out of range plaintext to fail")
+	}
+}
+
 // Test for Proving with custom serialization
 func TestSerializeProving(t *testing.T) {
 	// assert := test.NewAssert(t)
 	circuit, assignment := setupTls13OracleWrapperWrapper()
 
-	_, err := utils.ProofWithBackend("groth16", false, &circuit, &assignment, ecc.BN254)
+	res, err := utils.ProofWithBackend("groth16", false, &circuit, &assignment, ecc.BN254, nil)
 	// Proof successfully generated
 	if err != nil {
 		t.Fatalf("ProofWithBackend failed with error: %v", err)
 	}
+	if res.Proof == nil || res.Sizes["proof"] == 0 {
+		t.Fatalf("ProofWithBackend returned no proof")
+	}
+}
+
+// Test for per stage constraint counts and the pprof profile
+func TestOracleProfile(t *testing.T) {
+	circuit, _ := setupTls13OracleWrapperWrapper()
+
+	for _, backend := range []string{"groth16", "plonk"} {
+		circuit.Stages = utils.NewStages()
+		path := filepath.Join(t.TempDir(), "oracle.pprof")
+
+		breakdown, err := utils.ProfileWithBackend(backend, &circuit, circuit.Stages, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, stage := range []string{"kdc", "authtag", "gcm", "substring", "str2int", "policy"} {
+			if circuit.Stages.Count(stage) == 0 {
+				t.Fatalf("%s: no constraints counted for stage %s", backend, stage)
+			}
+		}
+		if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+			t.Fatalf("%s: missing pprof profile: %v", backend, err)
+		}
+
+		t.Logf("%s\n%s", backend, breakdown)
+	}
 }
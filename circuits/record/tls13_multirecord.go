@@ -0,0 +1,204 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package record
+
+import (
+	comparator "circuits/comparator"
+	utils "circuits/utils"
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// evaluate several records of one response
+type MultiRecordWrapper struct {
+	Key            [16]frontend.Variable
+	Iv             [12]frontend.Variable `gnark:",public"`
+	Records        []RecordSegment
+	Substring      []frontend.Variable `gnark:",public"`
+	SubstringStart int                 `gnark:",public"`
+	SubstringEnd   int                 `gnark:",public"`
+	ValueStart     int                 `gnark:",public"`
+	ValueEnd       int                 `gnark:",public"`
+	Threshold      frontend.Variable   `gnark:",public"`
+}
+
+func (circuit *MultiRecordWrapper) Define(api frontend.API) error {
+
+	// private inputs are bytes
+	utils.AssertIsBytes(api, circuit.Key[:])
+	for _, r := range circuit.Records {
+		utils.AssertIsBytes(api, r.PlainChunks)
+	}
+
+	records := NewMultiRecord(api)
+
+	// insert data
+	records.SetParams(
+		circuit.Key[:],
+		circuit.Iv,
+		circuit.Records,
+		circuit.Substring,
+		circuit.Threshold,
+		circuit.SubstringStart,
+		circuit.SubstringEnd,
+		circuit.ValueStart,
+		circuit.ValueEnd,
+	)
+
+	// verify
+	return records.Assert()
+}
+
+// chunks of one record, encrypted under its own sequence number
+type RecordSegment struct {
+	PlainChunks    []frontend.Variable
+	CipherChunks   []frontend.Variable  `gnark:",public"`
+	ChunkIndex     frontend.Variable    `gnark:",public"`
+	SequenceNumber [8]frontend.Variable `gnark:",public"`
+	// ciphertext length of the record without header and tag
+	Length frontend.Variable `gnark:",public"`
+	// trailing plaintext bytes left out of the concatenation, the inner content type and its zero padding
+	Trim int
+}
+
+// records decrypted under the shared traffic key, positions refer to the concatenated plaintext.
+// records have consecutive sequence numbers, every segment but the last ends at its record end and every segment but the first starts at its record start.
+type MultiRecord struct {
+	api            frontend.API
+	CipherSuite    uint16
+	Key            []frontend.Variable
+	Iv             [12]frontend.Variable // `gnark:",public"`
+	Records        []RecordSegment
+	Substring      []frontend.Variable // `gnark:",public"`
+	SubstringStart int                 // `gnark:",public"`
+	SubstringEnd   int                 // `gnark:",public"`
+	ValueStart     int                 // `gnark:",public"`
+	ValueEnd       int                 // `gnark:",public"`
+	Threshold      frontend.Variable   // `gnark:",public"`
+	Policy         comparator.Policy
+}
+
+func NewMultiRecord(api frontend.API) MultiRecord {
+	return MultiRecord{api: api, CipherSuite: TLS_AES_128_GCM_SHA256}
+}
+
+// selects the record cipher, the key length must match the suite
+func (circuit *MultiRecord) SetCipherSuite(suite uint16) {
+	circuit.CipherSuite = suite
+}
+
+// selects the numeric policy on the value, without bounds the policy compares against Threshold
+func (circuit *MultiRecord) SetPolicy(policy comparator.Policy) {
+	circuit.Policy = policy
+}
+
+func (circuit *MultiRecord) SetParams(key []frontend.Variable, iv [12]frontend.Variable, records []RecordSegment, substring []frontend.Variable, threshold frontend.Variable, substringStart, substringEnd, valueStart, valueEnd int) {
+	circuit.Key = key
+	circuit.Iv = iv
+	circuit.Records = records
+	circuit.Substring = substring
+	circuit.Threshold = threshold
+	circuit.SubstringStart = substringStart
+	circuit.SubstringEnd = substringEnd
+	circuit.ValueStart = valueStart
+	circuit.ValueEnd = valueEnd
+}
+
+// verifies the decryption of every record and returns the concatenated plaintext
+func (circuit *MultiRecord) Plaintext() ([]frontend.Variable, error) {
+
+	api := circuit.api
+
+	var plaintext []frontend.Variable
+	for i, r := range circuit.Records {
+		last := i == len(circuit.Records)-1
+		if r.Trim < 0 || r.Trim > len(r.PlainChunks) {
+			return nil, fmt.Errorf("record: trim of record %d out of range", i)
+		}
+		if !last && r.Trim == 0 {
+			return nil, fmt.Errorf("record: record %d must trim its inner content type", i)
+		}
+		if err := assertCipher(api, nil, circuit.CipherSuite, circuit.Key, nil, circuit.Iv, r.ChunkIndex, r.PlainChunks, r.CipherChunks, nil, r.SequenceNumber); err != nil {
+			return nil, err
+		}
+
+		// chunks lie inside the record, joined and trimmed chunks reach the record boundary
+		start, err := chunkOffset(api, circuit.CipherSuite, r.ChunkIndex)
+		if err != nil {
+			return nil, err
+		}
+		end := api.Add(start, len(r.PlainChunks))
+		if last && r.Trim == 0 {
+			api.AssertIsLessOrEqual(end, r.Length)
+		} else {
+			api.AssertIsEqual(end, r.Length)
+		}
+		if i > 0 {
+			api.AssertIsEqual(start, 0)
+			api.AssertIsEqual(sequenceValue(api, r.SequenceNumber), api.Add(sequenceValue(api, circuit.Records[i-1].SequenceNumber), 1))
+		}
+
+		// trimmed tail is the application_data content type followed by zero padding
+		tail := r.PlainChunks[len(r.PlainChunks)-r.Trim:]
+		for j := range tail {
+			if j == 0 {
+				api.AssertIsEqual(tail[j], 0x17)
+			} else {
+				api.AssertIsEqual(tail[j], 0)
+			}
+		}
+
+		plaintext = append(plaintext, r.PlainChunks[:len(r.PlainChunks)-r.Trim]...)
+	}
+	return plaintext, nil
+}
+
+// byte offset of the first chunk in the record, gcm data blocks start at counter 2 and chacha20 blocks at counter 1
+func chunkOffset(api frontend.API, suite uint16, chunkIndex frontend.Variable) (frontend.Variable, error) {
+	switch suite {
+	case TLS_AES_128_GCM_SHA256, TLS_AES_256_GCM_SHA384:
+		return api.Mul(api.Sub(chunkIndex, 2), 16), nil
+	case TLS_CHACHA20_POLY1305_SHA256:
+		return api.Mul(api.Sub(chunkIndex, 1), 64), nil
+	}
+	return nil, fmt.Errorf("record: unsupported cipher suite %#04x", suite)
+}
+
+// big endian value of the 8 byte sequence number
+func sequenceValue(api frontend.API, seq [8]frontend.Variable) frontend.Variable {
+	sum := frontend.Variable(0)
+	for i := range seq {
+		sum = api.Add(api.Mul(sum, 256), seq[i])
+	}
+	return sum
+}
+
+// Define declares the circuit's constraints
+func (circuit *MultiRecord) Assert() error {
+
+	plaintext, err := circuit.Plaintext()
+	if err != nil {
+		return err
+	}
+	if circuit.SubstringEnd > len(plaintext) || circuit.ValueEnd > len(plaintext) {
+		return fmt.Errorf("record: selection exceeds the concatenated plaintext")
+	}
+
+	// policy over the logical plaintext, the value may cross a record boundary
+	return assertPolicy(circuit.api, nil, plaintext, circuit.Substring, withThreshold(circuit.Policy, circuit.Threshold), circuit.SubstringStart, circuit.SubstringEnd, circuit.ValueStart, circuit.ValueEnd)
+}
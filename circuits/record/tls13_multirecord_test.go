@@ -0,0 +1,171 @@
+package record
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+// one record of a multi record response, the window [start, end) of its inner plaintext is used
+type testRecord struct {
+	inner      []byte
+	seq        uint64
+	start, end int
+	trim       int
+}
+
+// records sealed under one key, positions refer to the concatenated windows
+func buildMultiRecordWrapper(records []testRecord, value string) (MultiRecordWrapper, MultiRecordWrapper) {
+
+	var plaintext []byte
+	var assignSegments, circuitSegments []RecordSegment
+	for _, r := range records {
+		ciphertext := sealRecord(TLS_AES_128_GCM_SHA256, testKey, r.seq, r.inner)
+
+		plaintext = append(plaintext, r.inner[r.start:r.end-r.trim]...)
+
+		segment := RecordSegment{
+			PlainChunks:  make([]frontend.Variable, r.end-r.start),
+			CipherChunks: make([]frontend.Variable, r.end-r.start),
+			ChunkIndex:   r.start/16 + 2,
+			Length:       len(r.inner),
+			Trim:         r.trim,
+		}
+		assignBytes(segment.PlainChunks, r.inner[r.start:r.end])
+		assignBytes(segment.CipherChunks, ciphertext[r.start:r.end])
+		assignBytes(segment.SequenceNumber[:], sequenceNumber(r.seq))
+		assignSegments = append(assignSegments, segment)
+		circuitSegments = append(circuitSegments, RecordSegment{
+			PlainChunks:  make([]frontend.Variable, r.end-r.start),
+			CipherChunks: make([]frontend.Variable, r.end-r.start),
+			Trim:         r.trim,
+		})
+	}
+
+	substringStart := bytes.Index(plaintext, []byte(`"value"`))
+	valueStart := bytes.Index(plaintext, []byte(value))
+
+	assignment := MultiRecordWrapper{
+		Records:        assignSegments,
+		Substring:      make([]frontend.Variable, len(`"value"`)),
+		SubstringStart: substringStart,
+		SubstringEnd:   substringStart + len(`"value"`),
+		ValueStart:     valueStart,
+		ValueEnd:       valueStart + len(value),
+		Threshold:      38001,
+	}
+	assignBytes(assignment.Key[:], testKey)
+	assignBytes(assignment.Iv[:], testIv)
+	assignBytes(assignment.Substring, []byte(`"value"`))
+
+	circuit := MultiRecordWrapper{
+		Records:        circuitSegments,
+		Substring:      make([]frontend.Variable, len(`"value"`)),
+		SubstringStart: assignment.SubstringStart,
+		SubstringEnd:   assignment.SubstringEnd,
+		ValueStart:     assignment.ValueStart,
+		ValueEnd:       assignment.ValueEnd,
+	}
+
+	return circuit, assignment
+}
+
+// inner plaintext of content and content type, left padded with spaces to full blocks
+func innerPlaintext(content string) []byte {
+	inner := append([]byte(content), 0x17)
+	for len(inner)%16 != 0 {
+		inner = append([]byte{' '}, inner...)
+	}
+	return inner
+}
+
+// body split into two records, the value crosses the record boundary
+func setupMultiRecordWrapper() (MultiRecordWrapper, MultiRecordWrapper) {
+
+	body := `{"data":{"currency":"EUR","padding":"xxxxxxxxxxxxx","amount":{"value":"38002.20"}},"status":"ok","note":"multi record"}`
+	split := strings.Index(body, "38002") + 2
+
+	// last two blocks of the first record, first two blocks of the second record
+	first := innerPlaintext(body[:split])
+	second := append([]byte(body[split:]), 0x17)
+	return buildMultiRecordWrapper([]testRecord{
+		{inner: first, seq: 1, start: len(first) - 32, end: len(first), trim: 1},
+		{inner: second, seq: 2, start: 0, end: 32},
+	}, "38002")
+}
+
+// Test for Solving
+func TestMultiRecordSolving(t *testing.T) {
+	circuit, assignment := setupMultiRecordWrapper()
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// swapped sequence numbers
+	assignment.Records[0].SequenceNumber, assignment.Records[1].SequenceNumber = assignment.Records[1].SequenceNumber, assignment.Records[0].SequenceNumber
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected swapped sequence numbers to fail")
+	}
+}
+
+// windows of valid records that do not join into the response
+func TestMultiRecordRejectsNonAdjacent(t *testing.T) {
+
+	first := innerPlaintext(`{"data":{"amount":{"value":"38`)
+	tail := append([]byte(`002.20"},"note":"multi record"}`), 0x17)
+
+	// window ends before the record end, the record continues with a second value
+	head := append(append([]byte{}, first...), []byte(`,"fee":"1"}`)...)
+	head = append(head, 0x17, 0, 0, 0, 0)
+	circuit, assignment := buildMultiRecordWrapper([]testRecord{
+		{inner: head, seq: 1, start: len(first) - 32, end: len(first), trim: 1},
+		{inner: tail, seq: 2, start: 0, end: 32},
+	}, "38002")
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected window before the record end to fail")
+	}
+
+	// trimmed bytes that are not the content type
+	trimmed := innerPlaintext(`{"data":{"amount":{"value":"38`)
+	trimmed[len(trimmed)-1] = '0'
+	circuit, assignment = buildMultiRecordWrapper([]testRecord{
+		{inner: trimmed, seq: 1, start: len(trimmed) - 32, end: len(trimmed), trim: 1},
+		{inner: tail, seq: 2, start: 0, end: 32},
+	}, "38002")
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected trimmed plaintext to fail")
+	}
+
+	// record between the stitched records left out
+	circuit, assignment = buildMultiRecordWrapper([]testRecord{
+		{inner: first, seq: 1, start: len(first) - 32, end: len(first), trim: 1},
+		{inner: tail, seq: 3, start: 0, end: 32},
+	}, "38002")
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected non consecutive sequence numbers to fail")
+	}
+
+	// second window does not start at its record start
+	late := append([]byte(`0000000000000000002.20"},"note":"multi record"}`), 0x17)
+	circuit, assignment = buildMultiRecordWrapper([]testRecord{
+		{inner: first, seq: 1, start: len(first) - 32, end: len(first), trim: 1},
+		{inner: late, seq: 2, start: 16, end: 48},
+	}, "38002")
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected window after the record start to fail")
+	}
+
+	// adjacent windows of the same records solve
+	circuit, assignment = buildMultiRecordWrapper([]testRecord{
+		{inner: first, seq: 1, start: len(first) - 32, end: len(first), trim: 1},
+		{inner: tail, seq: 2, start: 0, end: 32},
+	}, "38002")
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -18,12 +18,24 @@ package record
 
 import (
 	aes128 "circuits/aes128"
+	aes128lookup "circuits/aes128lookup"
+	aes256 "circuits/aes256"
+	chacha20 "circuits/chacha20"
 	comparator "circuits/comparator"
 	conversion "circuits/str2int"
+	utils "circuits/utils"
+	"fmt"
 
 	"github.com/consensys/gnark/frontend"
 )
 
+// tls 1.3 cipher suites, rfc8446 appendix B.4
+const (
+	TLS_AES_128_GCM_SHA256       uint16 = 0x1301
+	TLS_AES_256_GCM_SHA384       uint16 = 0x1302
+	TLS_CHACHA20_POLY1305_SHA256 uint16 = 0x1303
+)
+
 // evaluate record
 type RecordWrapper struct {
 	Key            [16]frontend.Variable
@@ -37,16 +49,21 @@ type RecordWrapper struct {
 	ValueStart     int                   `gnark:",public"`
 	ValueEnd       int                   `gnark:",public"`
 	Threshold      frontend.Variable     `gnark:",public"`
+	Bounds         []frontend.Variable   `gnark:",public"`
 	SequenceNumber [8]frontend.Variable  `gnark:",public"`
+	Op             comparator.Op
 }
 
 func (circuit *RecordWrapper) Define(api frontend.API) error {
 
+	// private inputs are bytes
+	utils.AssertIsBytes(api, circuit.Key[:], circuit.PlainChunks)
+
 	record := NewTls13Record(api)
 
 	// insert data
 	record.SetParams(
-		circuit.Key,
+		circuit.Key[:],
 		circuit.Iv,
 		circuit.PlainChunks,
 		circuit.CipherChunks,
@@ -59,16 +76,17 @@ func (circuit *RecordWrapper) Define(api frontend.API) error {
 		circuit.ValueEnd,
 		circuit.SequenceNumber,
 	)
+	record.SetPolicy(comparator.Policy{Op: circuit.Op, Bounds: circuit.Bounds})
 
 	// verify
-	record.Assert()
-
-	return nil
+	return record.Assert()
 }
 
 type Tls13Record struct {
 	api            frontend.API
-	Key            [16]frontend.Variable
+	aes            aes128.AES
+	CipherSuite    uint16
+	Key            []frontend.Variable
 	PlainChunks    []frontend.Variable
 	Iv             [12]frontend.Variable // `gnark:",public"`
 	CipherChunks   []frontend.Variable   // `gnark:",public"`
@@ -80,13 +98,27 @@ type Tls13Record struct {
 	ValueEnd       int                   // `gnark:",public"`
 	Threshold      frontend.Variable     // `gnark:",public"`
 	SequenceNumber [8]frontend.Variable  // `gnark:",public"`
+	Policy         comparator.Policy
+	stages         *utils.Stages
+	expandedKey    []frontend.Variable
 }
 
+// aes128 records are decrypted with the lookup based aes128
 func NewTls13Record(api frontend.API) Tls13Record {
-	return Tls13Record{api: api}
+	return NewTls13RecordWithAES(api, nil)
+}
+
+// aes128 records are decrypted with the given implementation, nil selects the lookup based aes128
+func NewTls13RecordWithAES(api frontend.API, aes aes128.AES) Tls13Record {
+	return Tls13Record{api: api, aes: aes, CipherSuite: TLS_AES_128_GCM_SHA256}
+}
+
+// selects the record cipher, the key length must match the suite
+func (circuit *Tls13Record) SetCipherSuite(suite uint16) {
+	circuit.CipherSuite = suite
 }
 
-func (circuit *Tls13Record) SetParams(key [16]frontend.Variable, iv [12]frontend.Variable, plainChunks, cipherChunks, substring []frontend.Variable, chunkIndex, threshold frontend.Variable, substringStart, substringEnd, valueStart, valueEnd int, sequenceNumber [8]frontend.Variable) {
+func (circuit *Tls13Record) SetParams(key []frontend.Variable, iv [12]frontend.Variable, plainChunks, cipherChunks, substring []frontend.Variable, chunkIndex, threshold frontend.Variable, substringStart, substringEnd, valueStart, valueEnd int, sequenceNumber [8]frontend.Variable) {
 	circuit.Key = key
 	circuit.PlainChunks = plainChunks
 	circuit.Iv = iv
@@ -101,27 +133,146 @@ func (circuit *Tls13Record) SetParams(key [16]frontend.Variable, iv [12]frontend
 	circuit.SequenceNumber = sequenceNumber
 }
 
+// selects the numeric policy on the value, without bounds the policy compares against Threshold
+func (circuit *Tls13Record) SetPolicy(policy comparator.Policy) {
+	circuit.Policy = policy
+}
+
+// aes round keys of Key, e.g. shared with the tag of the record, nil expands Key
+func (circuit *Tls13Record) SetExpandedKey(expandedKey []frontend.Variable) {
+	circuit.expandedKey = expandedKey
+}
+
+// counts the constraints of decryption, substring, conversion and policy, nil counts nothing
+func (circuit *Tls13Record) SetStages(stages *utils.Stages) {
+	circuit.stages = stages
+}
+
 // Define declares the circuit's constraints
 func (circuit *Tls13Record) Assert() error {
 
-	// aes circuit
-	aes := aes128.NewAES128(circuit.api)
+	defer circuit.stages.End()
 
-	gcm := aes128.NewGCM(circuit.api, &aes)
+	// verify decryption of chunks
+	circuit.stages.Begin("gcm")
+	if err := assertCipher(circuit.api, circuit.aes, circuit.CipherSuite, circuit.Key, circuit.expandedKey, circuit.Iv, circuit.ChunkIndex, circuit.PlainChunks, circuit.CipherChunks, nil, circuit.SequenceNumber); err != nil {
+		return err
+	}
+
+	// continue with verified plaintext
+	return assertPolicy(circuit.api, circuit.stages, circuit.PlainChunks, circuit.Substring, withThreshold(circuit.Policy, circuit.Threshold), circuit.SubstringStart, circuit.SubstringEnd, circuit.ValueStart, circuit.ValueEnd)
+}
 
-	// verify aes gcm of chunks
-	gcm.Assert(circuit.Key, circuit.Iv, circuit.ChunkIndex, circuit.PlainChunks, circuit.CipherChunks, circuit.SequenceNumber)
+// verifies that cipherChunks encrypt plainChunks under the record cipher of the suite
+// a non-nil length restricts the check to the first length bytes, the remaining bytes must be zero
+// non-nil aes round keys of keyBytes replace the key expansion of unmasked chunks
+func assertCipher(api frontend.API, aes aes128.AES, suite uint16, keyBytes, expandedKey []frontend.Variable, iv [12]frontend.Variable, chunkIndex frontend.Variable, plainChunks, cipherChunks []frontend.Variable, length frontend.Variable, sequenceNumber [8]frontend.Variable) error {
 
-	// continue with verified plaintext, extract substring from it, and perform constraint check
-	extractedSubstring := circuit.PlainChunks[circuit.SubstringStart:circuit.SubstringEnd]
-	comparator.SubstringMatch(circuit.api, circuit.Substring, extractedSubstring, 0, len(circuit.Substring))
+	switch suite {
+	case TLS_AES_128_GCM_SHA256:
+		if len(keyBytes) != 16 {
+			return fmt.Errorf("record: TLS_AES_128_GCM_SHA256 requires a 16 byte key, got %d", len(keyBytes))
+		}
+		var key [16]frontend.Variable
+		copy(key[:], keyBytes)
+
+		// aes circuit
+		if aes == nil {
+			lookup := aes128lookup.NewAES128(api)
+			aes = &lookup
+		}
+
+		gcm := aes128.NewGCM(api, aes)
+
+		// verify aes gcm of chunks
+		switch {
+		case length != nil:
+			gcm.AssertMasked(key, iv, chunkIndex, plainChunks, cipherChunks, length, sequenceNumber)
+		case expandedKey != nil:
+			var roundKeys [176]frontend.Variable
+			if len(expandedKey) != len(roundKeys) {
+				return fmt.Errorf("record: TLS_AES_128_GCM_SHA256 requires %d round key bytes, got %d", len(roundKeys), len(expandedKey))
+			}
+			copy(roundKeys[:], expandedKey)
+			gcm.AssertExpanded(roundKeys, iv, chunkIndex, plainChunks, cipherChunks, sequenceNumber)
+		default:
+			gcm.Assert(key, iv, chunkIndex, plainChunks, cipherChunks, sequenceNumber)
+		}
+
+	case TLS_AES_256_GCM_SHA384:
+		if len(keyBytes) != 32 {
+			return fmt.Errorf("record: TLS_AES_256_GCM_SHA384 requires a 32 byte key, got %d", len(keyBytes))
+		}
+		var key [32]frontend.Variable
+		copy(key[:], keyBytes)
+
+		aes := aes256.NewAES256(api)
+
+		gcm := aes256.NewGCM(api, &aes)
+
+		switch {
+		case length != nil:
+			gcm.AssertMasked(key, iv, chunkIndex, plainChunks, cipherChunks, length, sequenceNumber)
+		case expandedKey != nil:
+			var roundKeys [240]frontend.Variable
+			if len(expandedKey) != len(roundKeys) {
+				return fmt.Errorf("record: TLS_AES_256_GCM_SHA384 requires %d round key bytes, got %d", len(roundKeys), len(expandedKey))
+			}
+			copy(roundKeys[:], expandedKey)
+			gcm.AssertExpanded(roundKeys, iv, chunkIndex, plainChunks, cipherChunks, sequenceNumber)
+		default:
+			gcm.Assert(key, iv, chunkIndex, plainChunks, cipherChunks, sequenceNumber)
+		}
+
+	case TLS_CHACHA20_POLY1305_SHA256:
+		if len(keyBytes) != 32 {
+			return fmt.Errorf("record: TLS_CHACHA20_POLY1305_SHA256 requires a 32 byte key, got %d", len(keyBytes))
+		}
+		var key [32]frontend.Variable
+		copy(key[:], keyBytes)
+
+		// chunk index is the chacha20 block counter of the first 64 byte chunk
+		chacha := chacha20.NewChaCha20(api)
+		if length != nil {
+			chacha.AssertMasked(key, iv, chunkIndex, plainChunks, cipherChunks, length, sequenceNumber)
+		} else {
+			chacha.Assert(key, iv, chunkIndex, plainChunks, cipherChunks, sequenceNumber)
+		}
+
+	default:
+		return fmt.Errorf("record: unsupported cipher suite %#04x", suite)
+	}
+
+	return nil
+}
+
+// extracts substring and value from the verified plaintext and performs the constraint checks
+// the steps are counted as stages substring, str2int and policy
+func assertPolicy(api frontend.API, stages *utils.Stages, plaintext, substring []frontend.Variable, policy comparator.Policy, substringStart, substringEnd, valueStart, valueEnd int) error {
+
+	// extract substring and compare
+	stages.Begin("substring")
+	if err := comparator.SubstringMatch(api, substring, plaintext, substringStart, substringEnd); err != nil {
+		return err
+	}
 
 	// convert string value to integer
-	valueString := circuit.PlainChunks[circuit.ValueStart:circuit.ValueEnd]
-	valueInteger := conversion.StringToInt(circuit.api, valueString)
+	stages.Begin("str2int")
+	valueString := plaintext[valueStart:valueEnd]
+	valueInteger, err := conversion.StringToInt(api, valueString)
+	if err != nil {
+		return err
+	}
 
 	// data constraint checks
-	comparator.GreaterThan(circuit.api, valueInteger, circuit.Threshold)
+	stages.Begin("policy")
+	return comparator.AssertPolicy(api, valueInteger, policy)
+}
 
-	return nil
+// policies without bounds compare against the threshold
+func withThreshold(policy comparator.Policy, threshold frontend.Variable) comparator.Policy {
+	if len(policy.Bounds) == 0 {
+		policy.Bounds = []frontend.Variable{threshold}
+	}
+	return policy
 }
@@ -0,0 +1,119 @@
+package record
+
+import (
+	"bytes"
+	"testing"
+
+	aes128 "circuits/aes128"
+	aes128lookup "circuits/aes128lookup"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+)
+
+// record circuit with a selectable aes128 implementation
+type recordAESWrapper struct {
+	Key            [16]frontend.Variable
+	PlainChunks    []frontend.Variable
+	Iv             [12]frontend.Variable `gnark:",public"`
+	CipherChunks   []frontend.Variable   `gnark:",public"`
+	ChunkIndex     frontend.Variable     `gnark:",public"`
+	Substring      []frontend.Variable   `gnark:",public"`
+	SubstringStart int                   `gnark:",public"`
+	SubstringEnd   int                   `gnark:",public"`
+	ValueStart     int                   `gnark:",public"`
+	ValueEnd       int                   `gnark:",public"`
+	Threshold      frontend.Variable     `gnark:",public"`
+	SequenceNumber [8]frontend.Variable  `gnark:",public"`
+	bitwise        bool
+}
+
+func (circuit *recordAESWrapper) Define(api frontend.API) error {
+
+	var impl aes128.AES
+	if circuit.bitwise {
+		aes := aes128.NewAES128(api)
+		impl = &aes
+	} else {
+		aes := aes128lookup.NewAES128(api)
+		impl = &aes
+	}
+
+	record := NewTls13RecordWithAES(api, impl)
+	record.SetParams(circuit.Key[:], circuit.Iv, circuit.PlainChunks, circuit.CipherChunks, circuit.Substring, circuit.ChunkIndex, circuit.Threshold, circuit.SubstringStart, circuit.SubstringEnd, circuit.ValueStart, circuit.ValueEnd, circuit.SequenceNumber)
+
+	return record.Assert()
+}
+
+func setupRecordAESWrapper(bitwise bool) (recordAESWrapper, recordAESWrapper) {
+
+	body := []byte(`{"amount":{"value":"38002.20"},"currency":"EUR"}`)
+	ciphertext := sealRecord(TLS_AES_128_GCM_SHA256, testKey, 1, body)
+
+	substringStart := bytes.Index(body, []byte(`"value"`))
+	valueStart := bytes.Index(body, []byte("38002"))
+
+	assignment := recordAESWrapper{
+		PlainChunks:    make([]frontend.Variable, len(body)),
+		CipherChunks:   make([]frontend.Variable, len(body)),
+		ChunkIndex:     2,
+		Substring:      make([]frontend.Variable, len(`"value"`)),
+		SubstringStart: substringStart,
+		SubstringEnd:   substringStart + len(`"value"`),
+		ValueStart:     valueStart,
+		ValueEnd:       valueStart + 5,
+		Threshold:      38001,
+	}
+	assignBytes(assignment.Key[:], testKey)
+	assignBytes(assignment.Iv[:], testIv)
+	assignBytes(assignment.SequenceNumber[:], sequenceNumber(1))
+	assignBytes(assignment.PlainChunks, body)
+	assignBytes(assignment.CipherChunks, ciphertext)
+	assignBytes(assignment.Substring, []byte(`"value"`))
+
+	circuit := recordAESWrapper{
+		PlainChunks:    make([]frontend.Variable, len(body)),
+		CipherChunks:   make([]frontend.Variable, len(body)),
+		Substring:      make([]frontend.Variable, len(`"value"`)),
+		SubstringStart: assignment.SubstringStart,
+		SubstringEnd:   assignment.SubstringEnd,
+		ValueStart:     assignment.ValueStart,
+		ValueEnd:       assignment.ValueEnd,
+		bitwise:        bitwise,
+	}
+
+	return circuit, assignment
+}
+
+// both aes128 implementations decrypt the record, constraint counts per backend
+func TestRecordAESImplementations(t *testing.T) {
+
+	counts := map[string]map[bool]int{}
+	for _, bitwise := range []bool{true, false} {
+		circuit, assignment := setupRecordAESWrapper(bitwise)
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatal(err)
+		}
+
+		for name, builder := range map[string]frontend.NewBuilder{"r1cs": r1cs.NewBuilder, "scs": scs.NewBuilder} {
+			ccs, err := frontend.Compile(ecc.BN254.ScalarField(), builder, &circuit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if counts[name] == nil {
+				counts[name] = map[bool]int{}
+			}
+			counts[name][bitwise] = ccs.GetNbConstraints()
+		}
+	}
+
+	for name, c := range counts {
+		t.Logf("%s constraints: bitwise %d, lookup %d", name, c[true], c[false])
+		if c[false] >= c[true] {
+			t.Errorf("%s: lookup aes128 is not cheaper than bitwise aes128", name)
+		}
+	}
+}
@@ -1,11 +1,13 @@
 package record
 
 import (
+	"bytes"
 	utils "circuits/utils"
 	"encoding/hex"
 	"encoding/json"
 	"testing"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/test"
@@ -118,8 +120,12 @@ func setupRecordWrapper() (RecordWrapper, RecordWrapper) {
 		ValueStart:     valueStart,
 		ValueEnd:       valueEnd,
 		Threshold:      threshold,
+		Bounds:         []frontend.Variable{threshold},
 	}
 
+	// the fixture chunks belong to the second record, sequence number 1
+	assignBytes(assignment.SequenceNumber[:], sequenceNumber(1))
+
 	// kdc assign
 	for i := 0; i < keyByteLen; i++ {
 		assignment.Key[i] = keyAssign[i]
@@ -146,6 +152,7 @@ func setupRecordWrapper() (RecordWrapper, RecordWrapper) {
 		SubstringEnd:   substringEnd,
 		ValueStart:     valueStart,
 		ValueEnd:       valueEnd,
+		Bounds:         make([]frontend.Variable, 1),
 	}
 
 	return circuit, assignment
@@ -168,3 +175,129 @@ func TestRecordProving(t *testing.T) {
 	// Proof successfully generated
 	assert.ProverSucceeded(&circuit, &assignment)
 }
+
+// Test for out of range private inputs
+func TestRecordRejectsNonBytes(t *testing.T) {
+	circuit, assignment := setupRecordWrapper()
+	assignment.PlainChunks[0] = assignment.PlainChunks[0].(int) + 256
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected out of range plaintext to fail")
+	}
+}
+
+// record circuit for cipher suites with 32 byte keys
+type key32RecordCircuit struct {
+	suite          uint16
+	Key            [32]frontend.Variable
+	PlainChunks    []frontend.Variable
+	Iv             [12]frontend.Variable `gnark:",public"`
+	CipherChunks   []frontend.Variable   `gnark:",public"`
+	ChunkIndex     frontend.Variable     `gnark:",public"`
+	Substring      []frontend.Variable   `gnark:",public"`
+	SubstringStart int
+	SubstringEnd   int
+	ValueStart     int
+	ValueEnd       int
+	Threshold      frontend.Variable    `gnark:",public"`
+	SequenceNumber [8]frontend.Variable `gnark:",public"`
+}
+
+func (circuit *key32RecordCircuit) Define(api frontend.API) error {
+	record := NewTls13Record(api)
+	record.SetCipherSuite(circuit.suite)
+	record.SetParams(circuit.Key[:], circuit.Iv, circuit.PlainChunks, circuit.CipherChunks, circuit.Substring, circuit.ChunkIndex, circuit.Threshold, circuit.SubstringStart, circuit.SubstringEnd, circuit.ValueStart, circuit.ValueEnd, circuit.SequenceNumber)
+	return record.Assert()
+}
+
+// Test for Solving
+func TestRecordChaCha20Solving(t *testing.T) {
+
+	body := []byte(`{"data":{"currency":"EUR","padding":"xxxxxxxxxxxxxxxxxxxxxxxx","amount":{"value":"38002.20"}},"status":"ok"}`)
+	ciphertext := sealRecord(TLS_CHACHA20_POLY1305_SHA256, testKey32, 3, body)
+
+	// second 64 byte chunk, counter 1 is the first chunk
+	plainChunks := body[64:]
+	cipherChunks := ciphertext[64:]
+	substringStart := bytes.Index(plainChunks, []byte(`"value"`))
+	valueStart := substringStart + len(`"value":"`)
+
+	assignment := key32RecordCircuit{
+		PlainChunks:    make([]frontend.Variable, len(plainChunks)),
+		CipherChunks:   make([]frontend.Variable, len(cipherChunks)),
+		ChunkIndex:     2,
+		Substring:      make([]frontend.Variable, len(`"value"`)),
+		SubstringStart: substringStart,
+		SubstringEnd:   substringStart + len(`"value"`),
+		ValueStart:     valueStart,
+		ValueEnd:       valueStart + len("38002"),
+		Threshold:      38001,
+	}
+	assignBytes(assignment.Key[:], testKey32)
+	assignBytes(assignment.Iv[:], testIv)
+	assignBytes(assignment.SequenceNumber[:], sequenceNumber(3))
+	assignBytes(assignment.PlainChunks, plainChunks)
+	assignBytes(assignment.CipherChunks, cipherChunks)
+	assignBytes(assignment.Substring, []byte(`"value"`))
+
+	circuit := key32RecordCircuit{
+		suite:          TLS_CHACHA20_POLY1305_SHA256,
+		PlainChunks:    make([]frontend.Variable, len(plainChunks)),
+		CipherChunks:   make([]frontend.Variable, len(cipherChunks)),
+		Substring:      make([]frontend.Variable, len(`"value"`)),
+		SubstringStart: assignment.SubstringStart,
+		SubstringEnd:   assignment.SubstringEnd,
+		ValueStart:     assignment.ValueStart,
+		ValueEnd:       assignment.ValueEnd,
+	}
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Test for Solving
+func TestRecordAES256Solving(t *testing.T) {
+
+	body := []byte(`{"data":{"currency":"EUR","amount":{"value":"38002.20"}},"status":"ok"}`)
+	ciphertext := sealRecord(TLS_AES_256_GCM_SHA384, testKey32, 1, body)
+
+	// third and fourth 16 byte chunks, counter 2 encrypts the first chunk
+	plainChunks := body[32:64]
+	cipherChunks := ciphertext[32:64]
+	substringStart := bytes.Index(plainChunks, []byte(`"value"`))
+	valueStart := substringStart + len(`"value":"`)
+
+	assignment := key32RecordCircuit{
+		PlainChunks:    make([]frontend.Variable, len(plainChunks)),
+		CipherChunks:   make([]frontend.Variable, len(cipherChunks)),
+		ChunkIndex:     4,
+		Substring:      make([]frontend.Variable, len(`"value"`)),
+		SubstringStart: substringStart,
+		SubstringEnd:   substringStart + len(`"value"`),
+		ValueStart:     valueStart,
+		ValueEnd:       valueStart + len("38002"),
+		Threshold:      38001,
+	}
+	assignBytes(assignment.Key[:], testKey32)
+	assignBytes(assignment.Iv[:], testIv)
+	assignBytes(assignment.SequenceNumber[:], sequenceNumber(1))
+	assignBytes(assignment.PlainChunks, plainChunks)
+	assignBytes(assignment.CipherChunks, cipherChunks)
+	assignBytes(assignment.Substring, []byte(`"value"`))
+
+	circuit := key32RecordCircuit{
+		suite:          TLS_AES_256_GCM_SHA384,
+		PlainChunks:    make([]frontend.Variable, len(plainChunks)),
+		CipherChunks:   make([]frontend.Variable, len(cipherChunks)),
+		Substring:      make([]frontend.Variable, len(`"value"`)),
+		SubstringStart: assignment.SubstringStart,
+		SubstringEnd:   assignment.SubstringEnd,
+		ValueStart:     assignment.ValueStart,
+		ValueEnd:       assignment.ValueEnd,
+	}
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
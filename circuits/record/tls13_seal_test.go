@@ -0,0 +1,62 @@
+package record
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+
+	utils "circuits/utils"
+
+	"github.com/consensys/gnark/frontend"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// traffic key and iv of the generated test records
+var (
+	testKey   = utils.MustHex("2872658573f95e87550cb26374e5f667")
+	testKey32 = utils.MustHex("1c9c7c260c39bcb8dcfa5fbc9330b9fa2872658573f95e87550cb26374e5f667")
+	testIv    = utils.MustHex("a54613bf2801a84ce693d0a0")
+)
+
+// big endian sequence number as used in the record nonce
+func sequenceNumber(seq uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], seq)
+	return b[:]
+}
+
+// encrypts the inner plaintext as record seq of the cipher suite, the tag is cut off
+func sealRecord(suite uint16, key []byte, seq uint64, plaintext []byte) []byte {
+
+	nonce := append([]byte{}, testIv...)
+	for i, b := range sequenceNumber(seq) {
+		nonce[4+i] ^= b
+	}
+
+	var aead cipher.AEAD
+	var err error
+	if suite == TLS_CHACHA20_POLY1305_SHA256 {
+		aead, err = chacha20poly1305.New(key)
+	} else {
+		var block cipher.Block
+		block, err = aes.NewCipher(key)
+		if err == nil {
+			aead, err = cipher.NewGCM(block)
+		}
+	}
+	if err != nil {
+		panic(err)
+	}
+
+	return aead.Seal(nil, nonce, plaintext, nil)[:len(plaintext)]
+}
+
+// assigns src to dst, remaining variables are zero
+func assignBytes(dst []frontend.Variable, src []byte) {
+	for i := range dst {
+		dst[i] = 0
+		if i < len(src) {
+			dst[i] = src[i]
+		}
+	}
+}
@@ -0,0 +1,231 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package record
+
+import (
+	aes128 "circuits/aes128"
+	comparator "circuits/comparator"
+	utils "circuits/utils"
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/selector"
+)
+
+// evaluate record with private positions
+type RecordSelectWrapper struct {
+	Key            [16]frontend.Variable
+	PlainChunks    []frontend.Variable
+	SubstringStart frontend.Variable
+	ValueStart     frontend.Variable
+	ValueEnd       frontend.Variable
+	Length         frontend.Variable     `gnark:",public"`
+	Iv             [12]frontend.Variable `gnark:",public"`
+	CipherChunks   []frontend.Variable   `gnark:",public"`
+	ChunkIndex     frontend.Variable     `gnark:",public"`
+	Substring      []frontend.Variable   `gnark:",public"`
+	Separator      []frontend.Variable   `gnark:",public"`
+	MaxValueLen    int                   `gnark:",public"`
+	Threshold      frontend.Variable     `gnark:",public"`
+	SequenceNumber [8]frontend.Variable  `gnark:",public"`
+}
+
+func (circuit *RecordSelectWrapper) Define(api frontend.API) error {
+
+	// private inputs are bytes
+	utils.AssertIsBytes(api, circuit.Key[:], circuit.PlainChunks)
+
+	record := NewTls13RecordSelect(api)
+
+	// insert data
+	record.SetParams(
+		circuit.Key[:],
+		circuit.Iv,
+		circuit.PlainChunks,
+		circuit.CipherChunks,
+		circuit.Substring,
+		circuit.ChunkIndex,
+		circuit.Threshold,
+		circuit.SubstringStart,
+		circuit.ValueStart,
+		circuit.ValueEnd,
+		circuit.MaxValueLen,
+		circuit.SequenceNumber,
+	)
+	record.SetLength(circuit.Length)
+	record.SetSeparator(circuit.Separator)
+
+	// verify
+	return record.Assert()
+}
+
+// record with positions as private variables, one circuit covers every offset inside the chunks
+// the value starts right after substring||separator, e.g. "price" and ":", and ends before the first non-digit byte.
+// the value is read from a window of MaxValueLen bytes and masked to ValueEnd
+// with a length set, the chunks are sized for a maximum record and only the first Length bytes are decrypted
+type Tls13RecordSelect struct {
+	api            frontend.API
+	aes            aes128.AES
+	CipherSuite    uint16
+	Key            []frontend.Variable
+	PlainChunks    []frontend.Variable
+	SubstringStart frontend.Variable
+	ValueStart     frontend.Variable
+	ValueEnd       frontend.Variable
+	Length         frontend.Variable     // `gnark:",public"`
+	Iv             [12]frontend.Variable // `gnark:",public"`
+	CipherChunks   []frontend.Variable   // `gnark:",public"`
+	ChunkIndex     frontend.Variable     // `gnark:",public"`
+	Substring      []frontend.Variable   // `gnark:",public"`
+	Separator      []frontend.Variable   // `gnark:",public"`
+	MaxValueLen    int                   // `gnark:",public"`
+	Threshold      frontend.Variable     // `gnark:",public"`
+	SequenceNumber [8]frontend.Variable  // `gnark:",public"`
+	Policy         comparator.Policy
+	stages         *utils.Stages
+	expandedKey    []frontend.Variable
+}
+
+// aes128 records are decrypted with the lookup based aes128
+func NewTls13RecordSelect(api frontend.API) Tls13RecordSelect {
+	return NewTls13RecordSelectWithAES(api, nil)
+}
+
+// aes128 records are decrypted with the given implementation, nil selects the lookup based aes128
+func NewTls13RecordSelectWithAES(api frontend.API, aes aes128.AES) Tls13RecordSelect {
+	return Tls13RecordSelect{api: api, aes: aes, CipherSuite: TLS_AES_128_GCM_SHA256}
+}
+
+// selects the record cipher, the key length must match the suite
+func (circuit *Tls13RecordSelect) SetCipherSuite(suite uint16) {
+	circuit.CipherSuite = suite
+}
+
+func (circuit *Tls13RecordSelect) SetParams(key []frontend.Variable, iv [12]frontend.Variable, plainChunks, cipherChunks, substring []frontend.Variable, chunkIndex, threshold, substringStart, valueStart, valueEnd frontend.Variable, maxValueLen int, sequenceNumber [8]frontend.Variable) {
+	circuit.Key = key
+	circuit.PlainChunks = plainChunks
+	circuit.Iv = iv
+	circuit.CipherChunks = cipherChunks
+	circuit.ChunkIndex = chunkIndex
+	circuit.Substring = substring
+	circuit.Threshold = threshold
+	circuit.SubstringStart = substringStart
+	circuit.ValueStart = valueStart
+	circuit.ValueEnd = valueEnd
+	circuit.MaxValueLen = maxValueLen
+	circuit.SequenceNumber = sequenceNumber
+}
+
+// selects the numeric policy on the value, without bounds the policy compares against Threshold
+func (circuit *Tls13RecordSelect) SetPolicy(policy comparator.Policy) {
+	circuit.Policy = policy
+}
+
+// bytes between substring and value, e.g. ":" or ":\"" for a json key with quotes
+func (circuit *Tls13RecordSelect) SetSeparator(separator []frontend.Variable) {
+	circuit.Separator = separator
+}
+
+// masks the chunks to the first length bytes, nil verifies all chunks
+func (circuit *Tls13RecordSelect) SetLength(length frontend.Variable) {
+	circuit.Length = length
+}
+
+// aes round keys of Key, e.g. shared with the tag of the record, nil expands Key
+func (circuit *Tls13RecordSelect) SetExpandedKey(expandedKey []frontend.Variable) {
+	circuit.expandedKey = expandedKey
+}
+
+// counts the constraints of decryption, substring, conversion and policy, nil counts nothing
+func (circuit *Tls13RecordSelect) SetStages(stages *utils.Stages) {
+	circuit.stages = stages
+}
+
+// Define declares the circuit's constraints
+func (circuit *Tls13RecordSelect) Assert() error {
+
+	if circuit.MaxValueLen <= 0 {
+		return fmt.Errorf("record: MaxValueLen must be positive")
+	}
+
+	defer circuit.stages.End()
+
+	// verify decryption of chunks
+	circuit.stages.Begin("gcm")
+	if err := assertCipher(circuit.api, circuit.aes, circuit.CipherSuite, circuit.Key, circuit.expandedKey, circuit.Iv, circuit.ChunkIndex, circuit.PlainChunks, circuit.CipherChunks, circuit.Length, circuit.SequenceNumber); err != nil {
+		return err
+	}
+
+	api := circuit.api
+
+	// substring and separator at the private offset
+	circuit.stages.Begin("substring")
+	key := append(append([]frontend.Variable{}, circuit.Substring...), circuit.Separator...)
+	if err := comparator.SubstringMatchAt(api, key, circuit.PlainChunks, circuit.SubstringStart, 0, len(circuit.PlainChunks)); err != nil {
+		return err
+	}
+
+	// the value directly follows the separator and has at least one digit
+	api.AssertIsEqual(circuit.ValueStart, api.Add(circuit.SubstringStart, len(key)))
+	api.AssertIsDifferent(circuit.ValueEnd, circuit.ValueStart)
+
+	// the byte after the value lies inside the chunks and is not a digit
+	next := selector.Mux(api, circuit.ValueEnd, circuit.PlainChunks...)
+	prod := frontend.Variable(1)
+	for d := 0; d < 10; d++ {
+		prod = api.Mul(prod, api.Sub(next, 48+d))
+	}
+	api.AssertIsDifferent(prod, 0)
+
+	// value of ValueEnd - ValueStart digits, zero padding fails the digit check
+	circuit.stages.Begin("str2int")
+	padded := append(append([]frontend.Variable{}, circuit.PlainChunks...), make([]frontend.Variable, circuit.MaxValueLen)...)
+	for i := len(circuit.PlainChunks); i < len(padded); i++ {
+		padded[i] = 0
+	}
+	window := ExtractWindow(api, padded, circuit.ValueStart, circuit.MaxValueLen)
+	valueInteger := StringToIntMasked(api, window, api.Sub(circuit.ValueEnd, circuit.ValueStart))
+
+	// data constraint checks
+	circuit.stages.Begin("policy")
+	return comparator.AssertPolicy(api, valueInteger, withThreshold(circuit.Policy, circuit.Threshold))
+}
+
+// returns in[start:start+n] for a variable start, the window must end inside in
+func ExtractWindow(api frontend.API, in []frontend.Variable, start frontend.Variable, n int) []frontend.Variable {
+
+	out := make([]frontend.Variable, n)
+	for i := range out {
+		out[i] = selector.Mux(api, api.Add(start, i), in...)
+	}
+	return out
+}
+
+// decimal value of the first length digits of window, length must not exceed len(window)
+func StringToIntMasked(api frontend.API, window []frontend.Variable, length frontend.Variable) frontend.Variable {
+
+	active := utils.PrefixMask(api, length, len(window))
+	sum := frontend.Variable(0)
+	for i := range window {
+		// masked digit, inactive bytes contribute 0 and keep the sum
+		digit := api.Mul(active[i], api.Sub(window[i], 48))
+		api.AssertIsLessOrEqual(digit, 9)
+		sum = api.Select(active[i], api.Add(api.Mul(sum, 10), digit), sum)
+	}
+
+	return sum
+}
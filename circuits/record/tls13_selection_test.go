@@ -0,0 +1,215 @@
+package record
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+// two bodies of equal size with the value at different offsets
+func setupRecordSelectWrapper(body []byte, value string) (RecordSelectWrapper, RecordSelectWrapper) {
+
+	ciphertext := sealRecord(TLS_AES_128_GCM_SHA256, testKey, 1, body)
+
+	// first four chunks
+	plainChunks := body[:64]
+	cipherChunks := ciphertext[:64]
+	substringStart := bytes.Index(plainChunks, []byte(`"value"`))
+	valueStart := bytes.Index(plainChunks, []byte(value))
+
+	assignment := RecordSelectWrapper{
+		PlainChunks:    make([]frontend.Variable, len(plainChunks)),
+		CipherChunks:   make([]frontend.Variable, len(cipherChunks)),
+		SubstringStart: substringStart,
+		ValueStart:     valueStart,
+		ValueEnd:       valueStart + len(value),
+		Length:         len(plainChunks),
+		ChunkIndex:     2,
+		Substring:      make([]frontend.Variable, len(`"value"`)),
+		Separator:      make([]frontend.Variable, len(`:"`)),
+		MaxValueLen:    8,
+		Threshold:      38001,
+	}
+	assignBytes(assignment.Key[:], testKey)
+	assignBytes(assignment.Iv[:], testIv)
+	assignBytes(assignment.SequenceNumber[:], sequenceNumber(1))
+	assignBytes(assignment.PlainChunks, plainChunks)
+	assignBytes(assignment.CipherChunks, cipherChunks)
+	assignBytes(assignment.Substring, []byte(`"value"`))
+	assignBytes(assignment.Separator, []byte(`:"`))
+
+	circuit := RecordSelectWrapper{
+		PlainChunks:  make([]frontend.Variable, len(plainChunks)),
+		CipherChunks: make([]frontend.Variable, len(cipherChunks)),
+		Substring:    make([]frontend.Variable, len(`"value"`)),
+		Separator:    make([]frontend.Variable, len(`:"`)),
+		MaxValueLen:  8,
+	}
+
+	return circuit, assignment
+}
+
+// Test for Solving
+func TestRecordSelectSolving(t *testing.T) {
+
+	bodies := []struct {
+		body  string
+		value string
+	}{
+		{`{"amount":{"value":"38002.20"},"currency":"EUR","status":"ok","id":7}`, "38002"},
+		{`{"currency":"EUR","amount":{"value":"1038002.20"},"status":"ok","id":7}`, "1038002"},
+	}
+
+	// one circuit definition for both offsets
+	circuit, _ := setupRecordSelectWrapper([]byte(bodies[0].body), bodies[0].value)
+	for _, b := range bodies {
+		_, assignment := setupRecordSelectWrapper([]byte(b.body), b.value)
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatal(b.value, err)
+		}
+	}
+
+	// the value must consist of digits only
+	_, assignment := setupRecordSelectWrapper([]byte(bodies[0].body), bodies[0].value)
+	assignment.ValueEnd = assignment.ValueEnd.(int) + 1
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected non-digit value to fail")
+	}
+
+	// the value must follow the substring
+	_, assignment = setupRecordSelectWrapper([]byte(bodies[0].body), bodies[0].value)
+	assignment.SubstringStart = assignment.ValueStart
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected misplaced substring to fail")
+	}
+}
+
+// selections that satisfy the threshold without being the value of the substring
+func TestRecordSelectRejectsForeignValue(t *testing.T) {
+
+	body := []byte(`{"amount":{"value":"12.20"},"fee":"99999","currency":"EUR","id":7}`)
+	circuit, assignment := setupRecordSelectWrapper(body, "12")
+	assignment.Threshold = 1
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// value taken from a later field
+	_, assignment = setupRecordSelectWrapper(body, "99999")
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected value of a later field to fail")
+	}
+
+	body = []byte(`{"amount":{"value":"38002.20"},"currency":"EUR","status":"ok","id":7}`)
+	circuit, _ = setupRecordSelectWrapper(body, "38002")
+
+	// value start shifted into the value
+	_, assignment = setupRecordSelectWrapper(body, "38002")
+	assignment.Threshold = 1000
+	assignment.ValueStart = assignment.ValueStart.(int) + 1
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected shifted value start to fail")
+	}
+
+	// value start shifted onto the separator
+	_, assignment = setupRecordSelectWrapper(body, "38002")
+	assignment.Threshold = 1000
+	assignment.ValueStart = assignment.ValueStart.(int) - 1
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected separator inside the value to fail")
+	}
+
+	// value truncated before its last digit
+	_, assignment = setupRecordSelectWrapper(body, "38002")
+	assignment.Threshold = 1000
+	assignment.ValueEnd = assignment.ValueEnd.(int) - 1
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected truncated value to fail")
+	}
+}
+
+// records of different sizes, chunks sized for the maximum record
+func setupRecordMaskedWrapper(body []byte, maxLen int) (RecordSelectWrapper, RecordSelectWrapper) {
+
+	ciphertext := sealRecord(TLS_AES_128_GCM_SHA256, testKey, 1, body)
+
+	substringStart := bytes.Index(body, []byte(`"value"`))
+	valueStart := bytes.Index(body, []byte("38002"))
+
+	assignment := RecordSelectWrapper{
+		PlainChunks:    make([]frontend.Variable, maxLen),
+		CipherChunks:   make([]frontend.Variable, maxLen),
+		SubstringStart: substringStart,
+		ValueStart:     valueStart,
+		ValueEnd:       valueStart + len("38002"),
+		Length:         len(body),
+		ChunkIndex:     2,
+		Substring:      make([]frontend.Variable, len(`"value"`)),
+		Separator:      make([]frontend.Variable, len(`:"`)),
+		MaxValueLen:    8,
+		Threshold:      38001,
+	}
+	assignBytes(assignment.Key[:], testKey)
+	assignBytes(assignment.Iv[:], testIv)
+	assignBytes(assignment.SequenceNumber[:], sequenceNumber(1))
+	assignBytes(assignment.PlainChunks, body)
+	assignBytes(assignment.CipherChunks, ciphertext)
+	assignBytes(assignment.Substring, []byte(`"value"`))
+	assignBytes(assignment.Separator, []byte(`:"`))
+
+	circuit := RecordSelectWrapper{
+		PlainChunks:  make([]frontend.Variable, maxLen),
+		CipherChunks: make([]frontend.Variable, maxLen),
+		Substring:    make([]frontend.Variable, len(`"value"`)),
+		Separator:    make([]frontend.Variable, len(`:"`)),
+		MaxValueLen:  8,
+	}
+
+	return circuit, assignment
+}
+
+// Test for Solving
+func TestRecordMaskedSolving(t *testing.T) {
+
+	bodies := []string{
+		`{"amount":{"value":"38002.20"}}`,
+		`{"currency":"EUR","amount":{"value":"38002.20"},"status":"ok","id":7}`,
+	}
+
+	// one circuit definition for both lengths, the last block is partial in both
+	circuit, _ := setupRecordMaskedWrapper([]byte(bodies[0]), 80)
+	for _, b := range bodies {
+		_, assignment := setupRecordMaskedWrapper([]byte(b), 80)
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatal(len(b), err)
+		}
+	}
+
+	// bytes past the length must be zero
+	_, assignment := setupRecordMaskedWrapper([]byte(bodies[0]), 80)
+	assignment.PlainChunks[len(bodies[0])] = 0x20
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected unmasked padding to fail")
+	}
+
+	// the length must cover the ciphertext
+	_, assignment = setupRecordMaskedWrapper([]byte(bodies[0]), 80)
+	assignment.Length = len(bodies[0]) - 1
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected short length to fail")
+	}
+}
+
+// Test for out of range private inputs
+func TestRecordSelectRejectsNonBytes(t *testing.T) {
+	body := `{"amount":{"value":"38002.20"},"currency":"EUR","status":"ok","id":7}`
+	circuit, assignment := setupRecordSelectWrapper([]byte(body), "38002")
+	assignment.PlainChunks[0] = int(body[0]) + 256
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected out of range plaintext to fail")
+	}
+}
@@ -0,0 +1,239 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package report
+
+import (
+	aes128 "circuits/aes128"
+	aes128lookup "circuits/aes128lookup"
+	aes256 "circuits/aes256"
+	authtag "circuits/authtag"
+	chacha20 "circuits/chacha20"
+	comparator "circuits/comparator"
+	hkdf "circuits/hkdf"
+	hmac "circuits/hmac"
+	json "circuits/json"
+	kdc "circuits/kdc"
+	origo "circuits/origo"
+	record "circuits/record"
+	sha256 "circuits/sha256"
+	sha256lookup "circuits/sha256lookup"
+	conversion "circuits/str2int"
+	utils "circuits/utils"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+)
+
+// record shape of the report circuits, a 64 byte record with an 8 byte key and a 4 digit value
+const (
+	recordLen    = 64
+	substringLen = 8
+	valueStart   = 10
+	valueEnd     = 14
+	hashLen      = 32
+	hash384Len   = 48
+	keyLen       = 16
+)
+
+// proof system backends of the report, one per constraint system builder
+var Backends = []string{"groth16", "plonk"}
+
+// constraint system statistics of one circuit
+type Count struct {
+	Constraints     int
+	PublicVariables int
+	SecretVariables int
+}
+
+// wrappers of all gadgets with fixed input sizes
+func Circuits() map[string]frontend.Circuit {
+
+	bytes := func(n int) []frontend.Variable {
+		return make([]frontend.Variable, n)
+	}
+
+	return map[string]frontend.Circuit{
+		"aes128":           &aes128.AES128Wrapper{},
+		"aes128_gcm":       &aes128.GCMWrapper{PlainChunks: bytes(recordLen), CipherChunks: bytes(recordLen)},
+		"aes128lookup":     &aes128lookup.AES128Wrapper{},
+		"aes128lookup_gcm": &aes128lookup.GCMWrapper{PlainChunks: bytes(recordLen), CipherChunks: bytes(recordLen)},
+		"aes256":           &aes256.AES256Wrapper{},
+		"aes256_gcm":       &aes256.GCMWrapper{PlainChunks: bytes(recordLen), CipherChunks: bytes(recordLen)},
+		"chacha20":         &chacha20.ChaCha20Wrapper{PlainChunks: bytes(recordLen), CipherChunks: bytes(recordLen)},
+		"poly1305":         &chacha20.Poly1305Wrapper{Ciphertext: bytes(recordLen)},
+		"authtag":          &authtag.AuthTagWrapper{},
+		"authtag_ghash":    &authtag.AuthTagGHashWrapper{Ciphertext: bytes(recordLen)},
+		"kdc":              &kdc.KdcWrapper{},
+		"kdc384":           &kdc.Kdc384Wrapper{},
+		"hmac":             &hmac.HMACWrapper{K: bytes(hashLen), Text: bytes(hashLen), Expected: bytes(hashLen)},
+		"hmac384":          &hmac.HMAC384Wrapper{K: bytes(hash384Len), Text: bytes(hash384Len), Expected: bytes(hash384Len)},
+		"hkdf":             &hkdf.HkdfWrapper{Secret: bytes(hashLen), Key: bytes(keyLen)},
+		"sha256":           &sha256.Sha256Wrapper{In: bytes(recordLen)},
+		"sha256lookup":     &sha256lookup.Sha256Wrapper{In: bytes(recordLen)},
+		"shacal2":          &sha256.Shacal2Wrapper{},
+		"sha384":           &sha256.Sha384Wrapper{In: bytes(recordLen)},
+		"sha512":           &sha256.Sha512Wrapper{In: bytes(recordLen)},
+		"shacal512":        &sha256.Shacal512Wrapper{},
+		"substring":        &comparator.SubstringWrapper{PlainChunks: bytes(recordLen), Substring: bytes(substringLen), SubstringStart: 0, SubstringEnd: substringLen},
+		"gtlt":             &comparator.GTLTWrapper{},
+		"str2int":          &conversion.Str2IntWrapper{PlainChunks: bytes(recordLen), ValueStart: valueStart, ValueEnd: valueEnd},
+		"record": &record.RecordWrapper{
+			PlainChunks:    bytes(recordLen),
+			CipherChunks:   bytes(recordLen),
+			Substring:      bytes(substringLen),
+			SubstringStart: 0,
+			SubstringEnd:   substringLen,
+			ValueStart:     valueStart,
+			ValueEnd:       valueEnd,
+		},
+		// the key is quoted at the start of the record, the value follows its opening quote
+		"json": &json.JsonWrapper{
+			Plaintext: bytes(recordLen),
+			Value:     bytes(valueEnd - valueStart),
+			Field:     json.Field{Path: []string{"price"}, KeyStarts: []int{1}, ValueStart: valueStart, ValueEnd: valueEnd, Quoted: true},
+		},
+		"record_select": &record.RecordSelectWrapper{
+			PlainChunks:  bytes(recordLen),
+			CipherChunks: bytes(recordLen),
+			Substring:    bytes(substringLen),
+			Separator:    bytes(2),
+			MaxValueLen:  valueEnd - valueStart,
+		},
+		// the record split over two records, the first one trimmed by its inner content type
+		"multirecord": &record.MultiRecordWrapper{
+			Records: []record.RecordSegment{
+				{PlainChunks: bytes(recordLen / 2), CipherChunks: bytes(recordLen / 2), Trim: 1},
+				{PlainChunks: bytes(recordLen / 2), CipherChunks: bytes(recordLen / 2)},
+			},
+			Substring:      bytes(substringLen),
+			SubstringStart: 0,
+			SubstringEnd:   substringLen,
+			ValueStart:     valueStart,
+			ValueEnd:       valueEnd,
+		},
+		// private positions, the value window of the prover
+		"oracle": &origo.Tls13OracleWrapper{
+			PlainChunks:  bytes(recordLen),
+			CipherChunks: bytes(recordLen),
+			Substring:    bytes(substringLen),
+			Separator:    bytes(2),
+			MaxValueLen:  origo.DefaultMaxValueLen,
+		},
+		"oracle_ghash": &origo.Tls13OracleGHashWrapper{
+			Ciphertext:     bytes(recordLen),
+			ChunkOffset:    0,
+			PlainChunks:    bytes(recordLen),
+			Substring:      bytes(substringLen),
+			SubstringStart: 0,
+			SubstringEnd:   substringLen,
+			ValueStart:     valueStart,
+			ValueEnd:       valueEnd,
+		},
+		// padded derived handshake secret and sha-512 chaining value of the sha-384 key schedule
+		"oracle_suite384": &origo.Tls13OracleSuiteWrapper{
+			CipherSuite:            record.TLS_AES_256_GCM_SHA384,
+			DHSin:                  bytes(128),
+			IntermediateHashHSopad: bytes(64),
+			MSin:                   bytes(hash384Len),
+			SATSin:                 bytes(hash384Len),
+			TkSAPPin:               bytes(hash384Len),
+			Ciphertext:             bytes(recordLen),
+			ChunkOffset:            0,
+			PlainChunks:            bytes(recordLen),
+			Substring:              bytes(substringLen),
+			SubstringStart:         0,
+			SubstringEnd:           substringLen,
+			ValueStart:             valueStart,
+			ValueEnd:               valueEnd,
+		},
+	}
+}
+
+// compiles the circuit with the constraint system builder of every backend
+func CountCircuit(circuit frontend.Circuit) (map[string]Count, error) {
+
+	counts := map[string]Count{}
+	for _, backend := range Backends {
+		builder, err := utils.BackendBuilder(backend)
+		if err != nil {
+			return nil, err
+		}
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), builder, circuit)
+		if err != nil {
+			return nil, err
+		}
+		counts[backend] = Count{
+			Constraints:     ccs.GetNbConstraints(),
+			PublicVariables: ccs.GetNbPublicVariables(),
+			SecretVariables: ccs.GetNbSecretVariables(),
+		}
+	}
+	return counts, nil
+}
+
+// counts of all circuits, keyed <circuit>_<backend>_<metric> as stored by utils.StoreM
+func Collect(circuits map[string]frontend.Circuit) (map[string]string, error) {
+
+	data := map[string]string{}
+	for name, circuit := range circuits {
+		counts, err := CountCircuit(circuit)
+		if err != nil {
+			return nil, fmt.Errorf("report: %s: %w", name, err)
+		}
+		for backend, c := range counts {
+			prefix := name + "_" + backend + "_"
+			data[prefix+"constraints"] = strconv.Itoa(c.Constraints)
+			data[prefix+"public_variables"] = strconv.Itoa(c.PublicVariables)
+			data[prefix+"secret_variables"] = strconv.Itoa(c.SecretVariables)
+		}
+	}
+	return data, nil
+}
+
+// returns an error listing every count above its baseline or without a baseline
+func Compare(data, baseline map[string]string) error {
+
+	var regressions []string
+	for key, value := range data {
+		current, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("report: %s: %w", key, err)
+		}
+		stored, ok := baseline[key]
+		if !ok {
+			regressions = append(regressions, fmt.Sprintf("%s: %d without baseline", key, current))
+			continue
+		}
+		limit, err := strconv.Atoi(stored)
+		if err != nil {
+			return fmt.Errorf("report: baseline %s: %w", key, err)
+		}
+		if current > limit {
+			regressions = append(regressions, fmt.Sprintf("%s: %d exceeds baseline %d", key, current, limit))
+		}
+	}
+
+	if len(regressions) > 0 {
+		sort.Strings(regressions)
+		return fmt.Errorf("report: constraint counts regressed\n%s", strings.Join(regressions, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package report
+
+import (
+	"flag"
+	"os"
+	"testing"
+
+	utils "circuits/utils"
+)
+
+// go test ./report -update rewrites the checked-in baseline
+var update = flag.Bool("update", false, "rewrite the constraint count baseline")
+
+const baselineDir = "testdata/"
+
+func TestConstraintRegression(t *testing.T) {
+	if testing.Short() {
+		t.Skip("compiles every circuit twice")
+	}
+
+	data, err := Collect(Circuits())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *update {
+		if err := utils.StoreM(data, baselineDir, "constraints"); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	// current counts next to the test binary output for inspection
+	dir := t.TempDir() + string(os.PathSeparator)
+	if err := utils.StoreM(data, dir, "constraints"); err != nil {
+		t.Fatal(err)
+	}
+	t.Logf("report written to %sconstraints.json", dir)
+
+	baseline, err := utils.LoadM(baselineDir, "constraints")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Compare(data, baseline); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+
+	baseline := map[string]string{"kdc_groth16_constraints": "100"}
+
+	if err := Compare(map[string]string{"kdc_groth16_constraints": "90"}, baseline); err != nil {
+		t.Fatal(err)
+	}
+	if err := Compare(map[string]string{"kdc_groth16_constraints": "101"}, baseline); err == nil {
+		t.Fatal("expected count above baseline to fail")
+	}
+	if err := Compare(map[string]string{"kdc_plonk_constraints": "1"}, baseline); err == nil {
+		t.Fatal("expected count without baseline to fail")
+	}
+}
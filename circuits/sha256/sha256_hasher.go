@@ -0,0 +1,63 @@
+/*
+MIT License
+
+Copyright (c) Jan Lauinger, 2023 zkCollective, Celer Network
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package sha256
+
+import (
+	"github.com/consensys/gnark/frontend"
+)
+
+// sha256 digest over byte variables
+type Hash interface {
+	Write(p []frontend.Variable) (int, error)
+	// compresses full blocks of p and returns the chaining value without padding
+	WriteReturn(p []frontend.Variable) [32]frontend.Variable
+	Sum() [32]frontend.Variable
+	Reset()
+}
+
+// creates digests of one sha256 implementation, the hmac and kdc gadgets select the implementation with it
+type Hasher interface {
+	New() Hash
+	NewWithIV(iv [32]frontend.Variable, length uint64) Hash
+}
+
+type bitwiseHasher struct {
+	api frontend.API
+}
+
+// hasher of the bitwise sha256 of this package
+func NewHasher(api frontend.API) Hasher {
+	return bitwiseHasher{api: api}
+}
+
+func (h bitwiseHasher) New() Hash {
+	d := NewSHA256(h.api)
+	return &d
+}
+
+func (h bitwiseHasher) NewWithIV(iv [32]frontend.Variable, length uint64) Hash {
+	d := NewSHA256WithIV(h.api, iv, length)
+	return &d
+}
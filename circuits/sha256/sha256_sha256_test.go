@@ -25,9 +25,12 @@ SOFTWARE.
 package sha256
 
 import (
+	"crypto/sha512"
+	"encoding"
 	"encoding/hex"
 	"testing"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/test"
@@ -81,3 +84,86 @@ func TestSha256All(t *testing.T) {
 	// Currently, this version of SHA256 only works with groth16
 	assert.SolvingSucceeded(&circuit, &assignment, test.WithBackends(backend.GROTH16))
 }
+
+func TestSha384(t *testing.T) {
+
+	for _, size := range []int{11, 112, 200} {
+		input := make([]byte, size)
+		for i := range input {
+			input[i] = byte(i * 7)
+		}
+		hash := sha512.Sum384(input)
+
+		assignment := Sha384Wrapper{In: make([]frontend.Variable, size)}
+		for i := range input {
+			assignment.In[i] = input[i]
+		}
+		for i := range hash {
+			assignment.Hash[i] = hash[i]
+		}
+		circuit := Sha384Wrapper{In: make([]frontend.Variable, size)}
+
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatal(size, err)
+		}
+	}
+}
+
+func TestSha512(t *testing.T) {
+
+	for _, size := range []int{0, 111, 128, 250} {
+		input := make([]byte, size)
+		for i := range input {
+			input[i] = byte(i * 13)
+		}
+		hash := sha512.Sum512(input)
+
+		assignment := Sha512Wrapper{In: make([]frontend.Variable, size)}
+		for i := range input {
+			assignment.In[i] = input[i]
+		}
+		for i := range hash {
+			assignment.Hash[i] = hash[i]
+		}
+		circuit := Sha512Wrapper{In: make([]frontend.Variable, size)}
+
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatal(size, err)
+		}
+	}
+}
+
+func TestShacal512(t *testing.T) {
+
+	// first block and padded tail of sha384(key || msg)
+	first := make([]byte, 128)
+	for i := range first {
+		first[i] = byte(i) ^ 0x5c
+	}
+	msg := make([]byte, 48)
+	for i := range msg {
+		msg[i] = byte(3 * i)
+	}
+	hash := sha512.Sum384(append(append([]byte{}, first...), msg...))
+
+	h := sha512.New384()
+	h.Write(first)
+	state, _ := h.(encoding.BinaryMarshaler).MarshalBinary()
+	tail := append(append([]byte{}, msg...), utils.PadSha512(128+48)...)
+
+	var assignment Shacal512Wrapper
+	for i := range assignment.DHSin {
+		assignment.DHSin[i] = tail[i]
+	}
+	for i := range assignment.IntermediateHashHSopad {
+		assignment.IntermediateHashHSopad[i] = state[4+i]
+	}
+	for i := range assignment.DHS {
+		assignment.DHS[i] = hash[i]
+	}
+
+	var circuit Shacal512Wrapper
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
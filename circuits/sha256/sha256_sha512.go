@@ -0,0 +1,268 @@
+/*
+MIT License
+
+Copyright (c) Jan Lauinger, 2023 zkCollective, Celer Network
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+
+package sha256
+
+import (
+	"github.com/consensys/gnark/frontend"
+)
+
+type Sha384Wrapper struct {
+	In   []frontend.Variable
+	Hash [48]frontend.Variable `gnark:",public"`
+}
+
+// Define declares the circuit's constraints
+func (circuit *Sha384Wrapper) Define(api frontend.API) error {
+
+	sha := NewSHA384(api)
+	sha.Write(circuit.In)
+	sum := sha.Sum()
+
+	for i := 0; i < 48; i++ {
+		api.AssertIsEqual(sum[i], circuit.Hash[i])
+	}
+
+	return nil
+}
+
+type Sha512Wrapper struct {
+	In   []frontend.Variable
+	Hash [64]frontend.Variable `gnark:",public"`
+}
+
+// Define declares the circuit's constraints
+func (circuit *Sha512Wrapper) Define(api frontend.API) error {
+
+	sha := NewSHA512(api)
+	sha.Write(circuit.In)
+	sum := sha.Sum()
+
+	for i := 0; i < 64; i++ {
+		api.AssertIsEqual(sum[i], circuit.Hash[i])
+	}
+
+	return nil
+}
+
+// sha384 resumed from the chaining value after one block
+type Shacal512Wrapper struct {
+	DHSin                  [128]frontend.Variable
+	IntermediateHashHSopad [64]frontend.Variable `gnark:",public"`
+	DHS                    [48]frontend.Variable `gnark:",public"`
+}
+
+// Define declares the circuit's constraints
+func (circuit *Shacal512Wrapper) Define(api frontend.API) error {
+
+	shacal := NewSHA384WithIV(api, circuit.IntermediateHashHSopad, 128)
+	out := shacal.WriteReturn(circuit.DHSin[:])
+
+	for i := 0; i < 48; i++ {
+		api.AssertIsEqual(out[i], circuit.DHS[i])
+	}
+
+	return nil
+}
+
+const (
+	chunk512 = 128
+	size384  = 48
+	size512  = 64
+)
+
+var init384 = [8]uint64{
+	0xcbbb9d5dc1059ed8,
+	0x629a292a367cd507,
+	0x9159015a3070dd17,
+	0x152fecd8f70e5939,
+	0x67332667ffc00b31,
+	0x8eb44a8768581511,
+	0xdb0c2e0d64f98fa7,
+	0x47b5481dbefa4fa4,
+}
+
+var init512 = [8]uint64{
+	0x6a09e667f3bcc908,
+	0xbb67ae8584caa73b,
+	0x3c6ef372fe94f82b,
+	0xa54ff53a5f1d36f1,
+	0x510e527fade682d1,
+	0x9b05688c2b3e6c1f,
+	0x1f83d9abfb41bd6b,
+	0x5be0cd19137e2179,
+}
+
+// sha512 family digest, the output size selects the truncation
+type digest512 struct {
+	h    [8]xuint64
+	x    [chunk512]xuint8 // 128 byte
+	nx   int
+	len  uint64
+	size int
+	init [8]uint64
+	api  frontend.API
+}
+
+func (d *digest512) Reset() {
+	for i := range d.h {
+		d.h[i] = constUint64(d.init[i])
+	}
+	d.nx = 0
+	d.len = 0
+}
+
+// chaining value of 64 big endian bytes, length is the number of bytes already hashed
+func (d *digest512) ResetWithIV(iv [64]frontend.Variable, length uint64) {
+	d.nx = 0
+	d.len = length
+
+	u8api := newUint8API(d.api)
+	for i := 0; i < 8; i++ {
+		var h xuint64
+		for j := 0; j < 8; j++ {
+			b := u8api.asUint8(iv[8*i+j])
+			copy(h[(7-j)*8:], b[:])
+		}
+		d.h[i] = h
+	}
+}
+
+func NewSHA384(api frontend.API) digest512 {
+	res := digest512{api: api, size: size384, init: init384}
+	res.Reset()
+	return res
+}
+
+func NewSHA384WithIV(api frontend.API, iv [64]frontend.Variable, length uint64) digest512 {
+	res := digest512{api: api, size: size384, init: init384}
+	res.ResetWithIV(iv, length)
+	return res
+}
+
+func NewSHA512(api frontend.API) digest512 {
+	res := digest512{api: api, size: size512, init: init512}
+	res.Reset()
+	return res
+}
+
+func NewSHA512WithIV(api frontend.API, iv [64]frontend.Variable, length uint64) digest512 {
+	res := digest512{api: api, size: size512, init: init512}
+	res.ResetWithIV(iv, length)
+	return res
+}
+
+// p: byte array, returns the chaining value without padding
+func (d *digest512) WriteReturn(p []frontend.Variable) [64]frontend.Variable {
+	d.Write(p)
+	var dv [64]frontend.Variable
+	copy(dv[:], d.chainingValue())
+	return dv
+}
+
+// p: byte array
+func (d *digest512) Write(p []frontend.Variable) (nn int, err error) {
+	var in []xuint8
+	for i := range p {
+		in = append(in, newUint8API(d.api).asUint8(p[i]))
+	}
+	return d.write(in)
+}
+
+func (d *digest512) write(p []xuint8) (nn int, err error) {
+	nn = len(p)
+	d.len += uint64(nn)
+
+	if d.nx > 0 {
+		n := copy(d.x[d.nx:], p)
+		d.nx += n
+		if d.nx == chunk512 {
+			blockGeneric512(d, d.x[:])
+			d.nx = 0
+		}
+		p = p[n:]
+	}
+
+	if len(p) >= chunk512 {
+		n := len(p) &^ (chunk512 - 1)
+		blockGeneric512(d, p[:n])
+		p = p[n:]
+	}
+
+	if len(p) > 0 {
+		d.nx = copy(d.x[:], p)
+	}
+
+	return
+}
+
+// digest of d.size bytes
+func (d *digest512) Sum() []frontend.Variable {
+
+	d0 := *d
+	return d0.checkSum()
+}
+
+func (d *digest512) checkSum() []frontend.Variable {
+	// Padding
+	len := d.len
+	var tmp [128]xuint8
+	tmp[0] = constUint8(0x80)
+	for i := 1; i < 128; i++ {
+		tmp[i] = constUint8(0x0)
+	}
+	if len%128 < 112 {
+		d.write(tmp[0 : 112-len%128])
+	} else {
+		d.write(tmp[0 : 128+112-len%128])
+	}
+
+	// fill 128 bit length, upper half is zero
+	len <<= 3
+	tmp[0] = constUint8(0x0)
+	PutUint64(d.api, tmp[8:], constUint64(len))
+	d.write(tmp[0:16])
+
+	if d.nx != 0 {
+		panic("d.nx != 0")
+	}
+
+	return d.chainingValue()[:d.size]
+}
+
+// h[0]..h[7] as big endian bytes
+func (d *digest512) chainingValue() []frontend.Variable {
+
+	var digest [64]xuint8
+	for i := 0; i < 8; i++ {
+		PutUint64(d.api, digest[8*i:], d.h[i])
+	}
+
+	u8api := newUint8API(d.api)
+	dv := make([]frontend.Variable, 64)
+	for i := range dv {
+		dv[i] = u8api.fromUint8(digest[i])
+	}
+	return dv
+}
@@ -84,6 +84,19 @@ func (w *uint64api) and(in ...xuint64) xuint64 {
 	return res
 }
 
+func (w *uint64api) or(in ...xuint64) xuint64 {
+	var res xuint64
+	for i := range res {
+		res[i] = 0
+	}
+	for i := range res {
+		for _, v := range in {
+			res[i] = w.api.Or(res[i], v[i])
+		}
+	}
+	return res
+}
+
 func (w *uint64api) xor(in ...xuint64) xuint64 {
 	var res xuint64
 	for i := range res {
@@ -146,6 +159,20 @@ func (w *uint64api) lshift(in xuint64, shift int) xuint64 {
 	return res
 }
 
+func (w *uint64api) add(i1, i2 xuint64, in ...xuint64) xuint64 {
+	var v []frontend.Variable
+	for _, i := range in {
+		v = append(v, w.fromUint64(i))
+	}
+	sum := w.api.Add(w.fromUint64(i1), w.fromUint64(i2), v...)
+
+	b := bits.ToBinary(w.api, sum, bits.WithNbDigits(65+len(in)))
+	var res xuint64
+	copy(res[:], b)
+
+	return res
+}
+
 func (in xuint64) toxUnit8() xuint8 {
 	var res xuint8
 	for i := 0; i < 8; i++ {
@@ -134,3 +134,14 @@ func (a xuint8) toUint32() xuint32 {
 	}
 	return res
 }
+
+func (a xuint8) toUint64() xuint64 {
+	var res xuint64
+	for i := 0; i < 8; i++ {
+		res[i] = a[i]
+	}
+	for i := 8; i < 64; i++ {
+		res[i] = 0
+	}
+	return res
+}
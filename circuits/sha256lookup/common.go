@@ -0,0 +1,223 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sha256lookup
+
+import (
+	"math/bits"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/lookup/logderivlookup"
+)
+
+// 32 bit word, bits least significant first
+// rotations and shifts reorder the bits, the boolean functions are looked up per nibble
+type Word struct {
+	bits    [32]frontend.Variable
+	value   frontend.Variable
+	nibbles *[8]frontend.Variable
+}
+
+// constant word
+func ConstWord(v uint32) *Word {
+	w := &Word{value: v}
+	for i := range w.bits {
+		w.bits[i] = (v >> i) & 1
+	}
+	return w
+}
+
+// nibble lookup tables shared by all words of a circuit
+type Gadget struct {
+	api frontend.API
+	// xor3[a<<8|b<<4|c] = a^b^c
+	xor3 *table
+	// ch[e<<8|f<<4|g] = (e&f)^(^e&g)
+	ch *table
+	// maj[a<<8|b<<4|c] = (a&b)^(a&c)^(b&c)
+	maj *table
+}
+
+// retuns Gadget instance which can be used inside a circuit
+func NewGadget(api frontend.API) Gadget {
+
+	xor3 := newTable(api, func(x, y, z int) int {
+		return x ^ y ^ z
+	})
+	ch := newTable(api, func(x, y, z int) int {
+		return (x & y) ^ (^x & z & 0xf)
+	})
+	maj := newTable(api, func(x, y, z int) int {
+		return (x & y) ^ (x & z) ^ (y & z)
+	})
+
+	return Gadget{api: api, xor3: xor3, ch: ch, maj: maj}
+}
+
+// lookup table over three nibbles, the entries are kept for constant indices
+type table struct {
+	*logderivlookup.Table
+	entries []int
+}
+
+func newTable(api frontend.API, f func(x, y, z int) int) *table {
+	t := &table{Table: logderivlookup.New(api), entries: make([]int, 4096)}
+	for i := range t.entries {
+		t.entries[i] = f(i>>8, (i>>4)&0xf, i&0xf)
+		t.Insert(t.entries[i])
+	}
+	return t
+}
+
+// word of 4 big endian bytes, the bytes are range checked by their bits
+func (g *Gadget) FromBytes(b []frontend.Variable) *Word {
+	w := &Word{}
+	for i := 0; i < 4; i++ {
+		copy(w.bits[8*i:], g.api.ToBinary(b[3-i], 8))
+	}
+	return w
+}
+
+// big endian bytes of the word
+func (g *Gadget) ToBytes(w *Word) [4]frontend.Variable {
+	var b [4]frontend.Variable
+	for i := 0; i < 4; i++ {
+		b[3-i] = g.compose(w.bits[8*i : 8*i+8])
+	}
+	return b
+}
+
+// value of the word
+func (g *Gadget) Value(w *Word) frontend.Variable {
+	if w.value == nil {
+		w.value = g.compose(w.bits[:])
+	}
+	return w.value
+}
+
+// value of nibbles returned by the lookups
+func (g *Gadget) NibbleValue(n [8]frontend.Variable) frontend.Variable {
+	v := frontend.Variable(0)
+	for i := 7; i >= 0; i-- {
+		v = g.api.Add(g.api.Mul(v, 16), n[i])
+	}
+	return v
+}
+
+// sum of values modulo 2^32, the values are words of at most 32 bits
+func (g *Gadget) Add(values ...frontend.Variable) *Word {
+	return g.addBounded(len(values), values...)
+}
+
+// sum of values modulo 2^32, the sum is below nbTerms*2^32
+func (g *Gadget) addBounded(nbTerms int, values ...frontend.Variable) *Word {
+	api := g.api
+
+	sum := frontend.Variable(0)
+	for _, v := range values {
+		sum = api.Add(sum, v)
+	}
+
+	// carry bits of the sum
+	nbBits := 32 + bits.Len(uint(nbTerms-1))
+	sumBits := api.ToBinary(sum, nbBits)
+
+	w := &Word{}
+	copy(w.bits[:], sumBits[:32])
+	w.value = api.Sub(sum, api.Mul(g.compose(sumBits[32:]), uint64(1)<<32))
+	return w
+}
+
+// nibbles of a^b^c
+func (g *Gadget) Xor3(a, b, c *Word) [8]frontend.Variable {
+	return g.lookup(g.xor3, a, b, c)
+}
+
+// nibbles of (e&f)^(^e&h)
+func (g *Gadget) Ch(e, f, h *Word) [8]frontend.Variable {
+	return g.lookup(g.ch, e, f, h)
+}
+
+// nibbles of (a&b)^(a&c)^(b&c)
+func (g *Gadget) Maj(a, b, c *Word) [8]frontend.Variable {
+	return g.lookup(g.maj, a, b, c)
+}
+
+// right rotation by r bits
+func (g *Gadget) Rotr(w *Word, r int) *Word {
+	out := &Word{}
+	for i := range out.bits {
+		out.bits[i] = w.bits[(i+r)%32]
+	}
+	return out
+}
+
+// right shift by r bits
+func (g *Gadget) Shr(w *Word, r int) *Word {
+	out := &Word{}
+	for i := range out.bits {
+		if i+r < 32 {
+			out.bits[i] = w.bits[i+r]
+		} else {
+			out.bits[i] = 0
+		}
+	}
+	return out
+}
+
+// nibble wise lookup of three words, constant indices are evaluated outside of the circuit
+func (g *Gadget) lookup(t *table, a, b, c *Word) [8]frontend.Variable {
+	api := g.api
+
+	na, nb, nc := g.nibbles(a), g.nibbles(b), g.nibbles(c)
+
+	var out [8]frontend.Variable
+	var pos []int
+	var inds []frontend.Variable
+	for i := range out {
+		ind := api.Add(api.Mul(na[i], 256), api.Mul(nb[i], 16), nc[i])
+		if v, ok := api.Compiler().ConstantValue(ind); ok {
+			out[i] = t.entries[v.Int64()]
+			continue
+		}
+		pos = append(pos, i)
+		inds = append(inds, ind)
+	}
+	for i, v := range t.Lookup(inds...) {
+		out[pos[i]] = v
+	}
+	return out
+}
+
+// nibble values of the word, computed once per word
+func (g *Gadget) nibbles(w *Word) *[8]frontend.Variable {
+	if w.nibbles == nil {
+		w.nibbles = new([8]frontend.Variable)
+		for i := range w.nibbles {
+			w.nibbles[i] = g.compose(w.bits[4*i : 4*i+4])
+		}
+	}
+	return w.nibbles
+}
+
+// value of little endian bits
+func (g *Gadget) compose(b []frontend.Variable) frontend.Variable {
+	v := frontend.Variable(0)
+	for i := len(b) - 1; i >= 0; i-- {
+		v = g.api.Add(g.api.Mul(v, 2), b[i])
+	}
+	return v
+}
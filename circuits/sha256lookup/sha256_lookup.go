@@ -0,0 +1,226 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sha256lookup
+
+import (
+	sha256 "circuits/sha256"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+type Sha256Wrapper struct {
+	In   []frontend.Variable
+	Hash [32]frontend.Variable `gnark:",public"`
+}
+
+// Define declares the circuit's constraints
+func (circuit *Sha256Wrapper) Define(api frontend.API) error {
+
+	sha := NewSHA256(api).New()
+	sha.Write(circuit.In)
+	sum := sha.Sum()
+
+	for i := 0; i < 32; i++ {
+		api.AssertIsEqual(sum[i], circuit.Hash[i])
+	}
+
+	return nil
+}
+
+const chunk = 64
+
+var init0 = [8]uint32{
+	0x6A09E667, 0xBB67AE85, 0x3C6EF372, 0xA54FF53A,
+	0x510E527F, 0x9B05688C, 0x1F83D9AB, 0x5BE0CD19,
+}
+
+// sha256 gadget, all digests of one gadget share the lookup tables
+type SHA256 struct {
+	g Gadget
+}
+
+// retuns a sha256 hasher which can be used in place of the bitwise sha256
+func NewSHA256(api frontend.API) SHA256 {
+	return SHA256{g: NewGadget(api)}
+}
+
+func (s SHA256) New() sha256.Hash {
+	d := &digest{g: &s.g}
+	d.Reset()
+	return d
+}
+
+// digest continuing from the chaining value iv after length bytes
+func (s SHA256) NewWithIV(iv [32]frontend.Variable, length uint64) sha256.Hash {
+	d := &digest{g: &s.g, len: length}
+	for i := 0; i < 8; i++ {
+		d.h[i] = s.g.FromBytes(iv[4*i : 4*i+4])
+	}
+	return d
+}
+
+type digest struct {
+	g   *Gadget
+	h   [8]*Word
+	x   [chunk]frontend.Variable
+	nx  int
+	len uint64
+}
+
+func (d *digest) Reset() {
+	for i := range d.h {
+		d.h[i] = ConstWord(init0[i])
+	}
+	d.nx = 0
+	d.len = 0
+}
+
+// p: byte array
+func (d *digest) Write(p []frontend.Variable) (nn int, err error) {
+	nn = len(p)
+	d.len += uint64(nn)
+
+	if d.nx > 0 {
+		n := copy(d.x[d.nx:], p)
+		d.nx += n
+		if d.nx == chunk {
+			d.block(d.x[:])
+			d.nx = 0
+		}
+		p = p[n:]
+	}
+
+	for len(p) >= chunk {
+		d.block(p[:chunk])
+		p = p[chunk:]
+	}
+
+	if len(p) > 0 {
+		d.nx = copy(d.x[:], p)
+	}
+
+	return
+}
+
+// chaining value after writing p, no padding is applied
+func (d *digest) WriteReturn(p []frontend.Variable) [32]frontend.Variable {
+	d.Write(p)
+	return d.bytes()
+}
+
+func (d *digest) Sum() [32]frontend.Variable {
+
+	d0 := *d
+
+	// padding
+	length := d0.len
+	var tmp [chunk]frontend.Variable
+	tmp[0] = 0x80
+	for i := 1; i < chunk; i++ {
+		tmp[i] = 0
+	}
+	if length%64 < 56 {
+		d0.Write(tmp[0 : 56-length%64])
+	} else {
+		d0.Write(tmp[0 : 64+56-length%64])
+	}
+
+	// fill length bit
+	length <<= 3
+	for i := 0; i < 8; i++ {
+		tmp[i] = (length >> (56 - 8*i)) & 0xff
+	}
+	d0.Write(tmp[0:8])
+
+	if d0.nx != 0 {
+		panic("d.nx != 0")
+	}
+
+	return d0.bytes()
+}
+
+func (d *digest) bytes() [32]frontend.Variable {
+	var out [32]frontend.Variable
+	for i := 0; i < 8; i++ {
+		b := d.g.ToBytes(d.h[i])
+		copy(out[4*i:], b[:])
+	}
+	return out
+}
+
+var _K = [64]uint32{
+	0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+	0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+	0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+	0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+	0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+	0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+	0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+	0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+}
+
+// sha256 compression of one 64 byte block
+func (d *digest) block(p []frontend.Variable) {
+
+	g := d.g
+	api := g.api
+
+	var w [64]*Word
+	for i := 0; i < 16; i++ {
+		w[i] = g.FromBytes(p[4*i : 4*i+4])
+	}
+	for i := 16; i < 64; i++ {
+		s0 := g.Xor3(g.Rotr(w[i-15], 7), g.Rotr(w[i-15], 18), g.Shr(w[i-15], 3))
+		s1 := g.Xor3(g.Rotr(w[i-2], 17), g.Rotr(w[i-2], 19), g.Shr(w[i-2], 10))
+		w[i] = g.Add(g.NibbleValue(s1), g.Value(w[i-7]), g.NibbleValue(s0), g.Value(w[i-16]))
+	}
+
+	a, b, c, dd, e, f, gg, h := d.h[0], d.h[1], d.h[2], d.h[3], d.h[4], d.h[5], d.h[6], d.h[7]
+
+	for i := 0; i < 64; i++ {
+		// t1 and t2 stay linear combinations, only the new words are decomposed
+		t1 := api.Add(
+			g.Value(h),
+			g.NibbleValue(g.Xor3(g.Rotr(e, 6), g.Rotr(e, 11), g.Rotr(e, 25))),
+			g.NibbleValue(g.Ch(e, f, gg)),
+			_K[i],
+			g.Value(w[i]),
+		)
+		t2 := api.Add(
+			g.NibbleValue(g.Xor3(g.Rotr(a, 2), g.Rotr(a, 13), g.Rotr(a, 22))),
+			g.NibbleValue(g.Maj(a, b, c)),
+		)
+
+		h = gg
+		gg = f
+		f = e
+		e = g.addBounded(6, g.Value(dd), t1)
+		dd = c
+		c = b
+		b = a
+		a = g.addBounded(7, t1, t2)
+	}
+
+	d.h[0] = g.Add(g.Value(d.h[0]), g.Value(a))
+	d.h[1] = g.Add(g.Value(d.h[1]), g.Value(b))
+	d.h[2] = g.Add(g.Value(d.h[2]), g.Value(c))
+	d.h[3] = g.Add(g.Value(d.h[3]), g.Value(dd))
+	d.h[4] = g.Add(g.Value(d.h[4]), g.Value(e))
+	d.h[5] = g.Add(g.Value(d.h[5]), g.Value(f))
+	d.h[6] = g.Add(g.Value(d.h[6]), g.Value(gg))
+	d.h[7] = g.Add(g.Value(d.h[7]), g.Value(h))
+}
@@ -0,0 +1,123 @@
+package sha256lookup
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	bitwise "circuits/sha256"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+)
+
+func TestSha256Solving(t *testing.T) {
+
+	// lengths around the padding boundaries
+	for _, n := range []int{0, 11, 55, 56, 64, 100} {
+		in := make([]byte, n)
+		for i := range in {
+			in[i] = byte(7*i + 3)
+		}
+		sum := sha256.Sum256(in)
+
+		circuit := Sha256Wrapper{In: make([]frontend.Variable, n)}
+		assignment := Sha256Wrapper{In: make([]frontend.Variable, n)}
+		for i := range in {
+			assignment.In[i] = in[i]
+		}
+		for i := range sum {
+			assignment.Hash[i] = sum[i]
+		}
+
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatalf("length %d: %v", n, err)
+		}
+
+		// wrong digest
+		assignment.Hash[0] = (sum[0] + 1) % 255
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+			t.Fatalf("length %d: wrong digest accepted", n)
+		}
+	}
+}
+
+// digests of the lookup and the bitwise implementation, hasher nil compares both
+type hasherWrapper struct {
+	In     []frontend.Variable
+	IV     [32]frontend.Variable
+	hasher func(api frontend.API) bitwise.Hasher
+}
+
+func (circuit *hasherWrapper) Define(api frontend.API) error {
+
+	hashers := []bitwise.Hasher{bitwise.NewHasher(api), NewSHA256(api)}
+	if circuit.hasher != nil {
+		hashers = []bitwise.Hasher{circuit.hasher(api)}
+	}
+
+	var sums, chains [][32]frontend.Variable
+	for _, h := range hashers {
+		sha := h.New()
+		sha.Write(circuit.In)
+		sums = append(sums, sha.Sum())
+
+		// shacal step as used by the kdc
+		shacal := h.NewWithIV(circuit.IV, 64)
+		chains = append(chains, shacal.WriteReturn(circuit.In[:64]))
+	}
+
+	for i := 1; i < len(hashers); i++ {
+		for j := 0; j < 32; j++ {
+			api.AssertIsEqual(sums[0][j], sums[i][j])
+			api.AssertIsEqual(chains[0][j], chains[i][j])
+		}
+	}
+
+	return nil
+}
+
+func TestHasherEquality(t *testing.T) {
+
+	n := 100
+	circuit := hasherWrapper{In: make([]frontend.Variable, n)}
+	assignment := hasherWrapper{In: make([]frontend.Variable, n)}
+	for i := 0; i < n; i++ {
+		assignment.In[i] = (13*i + 5) % 256
+	}
+	for i := range assignment.IV {
+		assignment.IV[i] = (31*i + 1) % 256
+	}
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCompileGetConstraints(t *testing.T) {
+
+	hashers := map[string]func(api frontend.API) bitwise.Hasher{
+		"bitwise": bitwise.NewHasher,
+		"lookup": func(api frontend.API) bitwise.Hasher {
+			return NewSHA256(api)
+		},
+	}
+
+	for name, hasher := range hashers {
+		circuit := hasherWrapper{In: make([]frontend.Variable, 100), hasher: hasher}
+
+		r1css, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sparse, err := frontend.Compile(ecc.BN254.ScalarField(), scs.NewBuilder, &circuit)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// two blocks of sha256 and one shacal block
+		t.Logf("%s: r1cs constraints %d, scs constraints %d", name, r1css.GetNbConstraints(), sparse.GetNbConstraints())
+	}
+}
@@ -0,0 +1,102 @@
+package str2int
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/selector"
+)
+
+// fixed-point evaluation
+type FixedPointWrapper struct {
+	PlainChunks []frontend.Variable
+	Value       frontend.Variable `gnark:",public"`
+	ValueStart  int               `gnark:",public"`
+	ValueEnd    int               `gnark:",public"`
+	Scale       int
+}
+
+// Define declares the circuit's constraints
+func (circuit *FixedPointWrapper) Define(api frontend.API) error {
+
+	valueString := circuit.PlainChunks[circuit.ValueStart:circuit.ValueEnd]
+	valueFixed, err := StringToFixed(api, valueString, circuit.Scale)
+	if err != nil {
+		return err
+	}
+
+	api.AssertIsEqual(valueFixed, circuit.Value)
+
+	return nil
+}
+
+// decimal string [+-]digits[.digits] to value * 10^scale
+// negative values are returned as field negation, at most scale fractional digits are accepted
+func StringToFixed(api frontend.API, valueString []frontend.Variable, scale int) (frontend.Variable, error) {
+
+	n := len(valueString)
+	if n == 0 {
+		return nil, errors.New("str2int: empty value string")
+	}
+
+	// every byte may be an integer digit, the scale adds up to scale fractional digits
+	if max := MaxDigits(api.Compiler().Field()); n+scale > max {
+		return nil, fmt.Errorf("str2int: %d digits and scale %d overflow the field, at most %d", n, scale, max)
+	}
+
+	isMinus := api.IsZero(api.Sub(valueString[0], '-'))
+	hasSign := api.Add(isMinus, api.IsZero(api.Sub(valueString[0], '+')))
+	if n == 1 {
+		api.AssertIsEqual(hasSign, 0)
+	}
+
+	sum := frontend.Variable(0)
+	dots := frontend.Variable(0)
+	fracDigits := frontend.Variable(0)
+	for i := 0; i < n; i++ {
+		isDot := api.IsZero(api.Sub(valueString[i], '.'))
+
+		// every byte is a digit, the sign or the separator
+		isDigit := api.Sub(1, isDot)
+		if i == 0 {
+			isDigit = api.Sub(isDigit, hasSign)
+		}
+		digit := api.Mul(isDigit, api.Sub(valueString[i], 48))
+		AssertDigit(api, digit)
+
+		sum = api.Select(isDigit, api.Add(api.Mul(sum, 10), digit), sum)
+		fracDigits = api.Add(fracDigits, api.Mul(dots, isDigit))
+		dots = api.Add(dots, isDot)
+	}
+
+	// a single separator between the integer and fractional digits
+	api.AssertIsBoolean(dots)
+	api.AssertIsEqual(api.IsZero(api.Sub(valueString[0], '.')), 0)
+	api.AssertIsEqual(api.IsZero(api.Sub(valueString[n-1], '.')), 0)
+	if n > 1 {
+		api.AssertIsEqual(api.Mul(hasSign, api.IsZero(api.Sub(valueString[1], '.'))), 0)
+	}
+
+	// shift by the missing fractional digits, fails for more than scale digits
+	if scale == 0 {
+		api.AssertIsEqual(fracDigits, 0)
+		return api.Select(isMinus, api.Neg(sum), sum), nil
+	}
+	pow := make([]frontend.Variable, scale+1)
+	pow[0] = 1
+	for i := 1; i <= scale; i++ {
+		pow[i] = api.Mul(pow[i-1], 10)
+	}
+	fixed := api.Mul(sum, selector.Mux(api, api.Sub(scale, fracDigits), pow...))
+
+	return api.Select(isMinus, api.Neg(fixed), fixed), nil
+}
+
+// asserts 0 <= d <= 9
+func AssertDigit(api frontend.API, d frontend.Variable) {
+	b := bits.ToBinary(api, d, bits.WithNbDigits(4))
+	// values 10 to 15 set bit 3 together with bit 1 or 2
+	api.AssertIsEqual(api.Mul(b[3], api.Add(b[1], b[2])), 0)
+}
@@ -0,0 +1,113 @@
+package str2int
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/test"
+)
+
+func setupFixedPointWrapper(value string, scale int, fixed int64) (FixedPointWrapper, FixedPointWrapper) {
+
+	// value embedded in a json member
+	plain := `"price":"` + value + `",`
+	valueStart := len(`"price":"`)
+	valueEnd := valueStart + len(value)
+
+	// negative values are field negations
+	expected := new(big.Int).Mod(big.NewInt(fixed), ecc.BN254.ScalarField())
+
+	assignment := FixedPointWrapper{
+		PlainChunks: make([]frontend.Variable, len(plain)),
+		Value:       expected,
+		ValueStart:  valueStart,
+		ValueEnd:    valueEnd,
+		Scale:       scale,
+	}
+	for i := range plain {
+		assignment.PlainChunks[i] = plain[i]
+	}
+
+	circuit := FixedPointWrapper{
+		PlainChunks: make([]frontend.Variable, len(plain)),
+		ValueStart:  valueStart,
+		ValueEnd:    valueEnd,
+		Scale:       scale,
+	}
+
+	return circuit, assignment
+}
+
+// Test for Solving
+func TestFixedPointSolving(t *testing.T) {
+
+	valid := []struct {
+		value string
+		scale int
+		fixed int64
+	}{
+		{"38002.20", 2, 3800220},
+		{"38002.2", 4, 380022000},
+		{"38002", 2, 3800200},
+		{"+0.05", 2, 5},
+		{"-12.5", 2, -1250},
+		{"7", 0, 7},
+	}
+	for _, v := range valid {
+		circuit, assignment := setupFixedPointWrapper(v.value, v.scale, v.fixed)
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatal(v.value, err)
+		}
+	}
+}
+
+func TestFixedPointRejectsMalformed(t *testing.T) {
+
+	invalid := []struct {
+		value string
+		scale int
+		fixed int64
+	}{
+		{"38.0.2", 3, 38020},
+		{"3:.2", 1, 102},
+		{"38002.205", 2, 3800220},
+		{"38002.2", 0, 38002},
+		{".5", 1, 5},
+		{"5.", 1, 50},
+		{"-.5", 1, -5},
+		{"--1", 0, 1},
+		{"1-2", 0, 12},
+		{"-", 0, 0},
+	}
+	for _, v := range invalid {
+		circuit, assignment := setupFixedPointWrapper(v.value, v.scale, v.fixed)
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+			t.Fatal(v.value, "expected malformed value to fail")
+		}
+	}
+}
+
+// integer and scaled fractional digits share the digit limit of the field
+func TestFixedPointMaxDigits(t *testing.T) {
+
+	max := MaxDigits(ecc.BN254.ScalarField())
+	for _, v := range []struct {
+		n, scale int
+	}{{max - 2, 2}, {max - 1, 2}, {max + 1, 0}} {
+		circuit := FixedPointWrapper{
+			PlainChunks: make([]frontend.Variable, v.n),
+			ValueEnd:    v.n,
+			Scale:       v.scale,
+		}
+		_, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &circuit)
+		if v.n+v.scale <= max && err != nil {
+			t.Fatal(v, err)
+		}
+		if v.n+v.scale > max && err == nil {
+			t.Fatal(v, "expected too many digits to fail")
+		}
+	}
+}
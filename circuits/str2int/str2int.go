@@ -1,9 +1,11 @@
 package str2int
 
 import (
-	"math"
+	"fmt"
+	"math/big"
 
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
 )
 
 // str 2 int evaluation
@@ -12,31 +14,57 @@ type Str2IntWrapper struct {
 	Value       frontend.Variable `gnark:",public"`
 	ValueStart  int               `gnark:",public"`
 	ValueEnd    int               `gnark:",public"`
+	NbBits      int               // bit width of the value, 0 for no bound
 }
 
 // Define declares the circuit's constraints
 func (circuit *Str2IntWrapper) Define(api frontend.API) error {
 
 	valueString := circuit.PlainChunks[circuit.ValueStart:circuit.ValueEnd]
-	valueInteger := StringToInt(api, valueString)
+	var valueInteger frontend.Variable
+	var err error
+	if circuit.NbBits > 0 {
+		valueInteger, err = StringToIntBounded(api, valueString, circuit.NbBits)
+	} else {
+		valueInteger, err = StringToInt(api, valueString)
+	}
+	if err != nil {
+		return err
+	}
 
 	api.AssertIsEqual(valueInteger, circuit.Value)
 
 	return nil
 }
 
-// gnark string to integer conversion
-func StringToInt(api frontend.API, valueString []frontend.Variable) frontend.Variable {
-	// aggregation number
+// maximum number of decimal digits whose value cannot wrap around the field, 76 for bn254
+func MaxDigits(field *big.Int) int {
+	return len(field.String()) - 1
+}
+
+// gnark string to integer conversion, every byte must be an ascii digit
+func StringToInt(api frontend.API, valueString []frontend.Variable) (frontend.Variable, error) {
+
+	if max := MaxDigits(api.Compiler().Field()); len(valueString) > max {
+		return nil, fmt.Errorf("str2int: %d digits overflow the field, at most %d", len(valueString), max)
+	}
+
+	// aggregation number, front to back
 	sum := frontend.Variable(0)
-	// loop from back to front
-	for i := len(valueString); i > 0; i-- {
-		idx := len(valueString) - i
-
-		// expanded dezimal such that shift can be applied
-		// 4 bits cover numbers 0-9, little endian result, IMPORTANT: 8 required, otherwise unsatisfied constraint error
-		toInt := api.Sub(api.FromBinary(api.ToBinary(valueString[i-1], 8)...), 48)
-		sum = api.MulAcc(sum, toInt, int(math.Pow(float64(10), float64(idx))))
+	for i := range valueString {
+		digit := api.Sub(valueString[i], 48)
+		AssertDigit(api, digit)
+		sum = api.Add(api.Mul(sum, 10), digit)
+	}
+	return sum, nil
+}
+
+// string to integer conversion that fails if the value does not fit into nbBits bits
+func StringToIntBounded(api frontend.API, valueString []frontend.Variable, nbBits int) (frontend.Variable, error) {
+	sum, err := StringToInt(api, valueString)
+	if err != nil {
+		return nil, err
 	}
-	return sum
+	bits.ToBinary(api, sum, bits.WithNbDigits(nbBits))
+	return sum, nil
 }
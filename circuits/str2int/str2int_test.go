@@ -1,12 +1,14 @@
 package str2int
 
 import (
+	utils "circuits/utils"
 	"encoding/hex"
-	utils "gadgets/utils"
 	"testing"
 
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
 	"github.com/consensys/gnark/test"
 )
 
@@ -66,3 +68,70 @@ func TestRecordProving(t *testing.T) {
 	// Proof successfully generated
 	assert.ProverSucceeded(&circuit, &assignment)
 }
+
+// Test for rejected values
+func TestStringToIntRejects(t *testing.T) {
+
+	invalid := map[string]struct {
+		plain  string
+		value  int
+		nbBits int
+	}{
+		// ':' maps to 10 and inflates the value
+		"non digit": {"3:002", 40002, 0},
+		"sign":      {"-3800", 0, 0},
+		"space":     {"38 02", 38002, 0},
+		// 38002 needs 16 bits
+		"overflow": {"38002", 38002, 15},
+	}
+	for name, v := range invalid {
+		assignment := Str2IntWrapper{
+			PlainChunks: make([]frontend.Variable, len(v.plain)),
+			Value:       v.value,
+			ValueEnd:    len(v.plain),
+		}
+		for i := range v.plain {
+			assignment.PlainChunks[i] = v.plain[i]
+		}
+		circuit := Str2IntWrapper{
+			PlainChunks: make([]frontend.Variable, len(v.plain)),
+			ValueEnd:    len(v.plain),
+			NbBits:      v.nbBits,
+		}
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+			t.Fatal(name, "expected invalid value to fail")
+		}
+	}
+
+	// fits the declared width
+	circuit, assignment := setupRecordWrapper()
+	circuit.NbBits = 16
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// the digit limit follows the field of the compiler
+func TestStringToIntMaxDigits(t *testing.T) {
+
+	for _, curve := range []ecc.ID{ecc.BN254, ecc.BLS12_377} {
+		max := MaxDigits(curve.ScalarField())
+		for _, n := range []int{max, max + 1} {
+			circuit := Str2IntWrapper{
+				PlainChunks: make([]frontend.Variable, n),
+				ValueEnd:    n,
+			}
+			_, err := frontend.Compile(curve.ScalarField(), r1cs.NewBuilder, &circuit)
+			if n <= max && err != nil {
+				t.Fatal(curve, n, err)
+			}
+			if n > max && err == nil {
+				t.Fatal(curve, n, "expected too many digits to fail")
+			}
+		}
+	}
+
+	if max := MaxDigits(ecc.BN254.ScalarField()); max != 76 {
+		t.Fatalf("expected 76 digits for bn254, got %d", max)
+	}
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package utils
 
 import (
-	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
 	"strconv"
 	"time"
 
@@ -28,17 +31,154 @@ import (
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/backend/plonk"
 	"github.com/consensys/gnark/backend/plonkfri"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
 	"github.com/consensys/gnark/frontend/cs/scs"
 	"github.com/consensys/gnark/test"
 )
 
+// plonk keys are derived from a kzg srs, there is no setup without one
+var ErrMissingSRS = errors.New("plonk setup requires a kzg srs")
+
+// returns the constraint system builder of a proof system backend
+func BackendBuilder(backend string) (frontend.NewBuilder, error) {
+	switch backend {
+	case "groth16":
+		return r1cs.NewBuilder, nil
+	case "plonk":
+		return scs.NewBuilder, nil
+	case "plonkFRI":
+		return scs.NewBuilder, nil
+	}
+	return nil, fmt.Errorf("unknown backend %q", backend)
+}
+
+// runs the setup of a proof system backend, plonk keys are derived from the kzg srs
+func BackendSetup(backend string, ccs constraint.ConstraintSystem, srs kzg.SRS) (pk, vk any, err error) {
+	switch backend {
+	case "groth16":
+		return groth16.Setup(ccs)
+	case "plonk":
+		if srs == nil {
+			return nil, nil, ErrMissingSRS
+		}
+		return plonk.Setup(ccs, srs)
+	case "plonkFRI":
+		return plonkfri.Setup(ccs)
+	}
+	return nil, nil, fmt.Errorf("unknown backend %q", backend)
+}
+
+// proves the full witness with the proving key returned by BackendSetup
+func BackendProve(backend string, ccs constraint.ConstraintSystem, pk any, fullWitness witness.Witness) (any, error) {
+	switch backend {
+	case "groth16":
+		return groth16.Prove(ccs, pk.(groth16.ProvingKey), fullWitness)
+	case "plonk":
+		return plonk.Prove(ccs, pk.(plonk.ProvingKey), fullWitness)
+	case "plonkFRI":
+		return plonkfri.Prove(ccs, pk.(plonkfri.ProvingKey), fullWitness)
+	}
+	return nil, fmt.Errorf("unknown backend %q", backend)
+}
+
+// verifies a proof of BackendProve against the verifying key returned by BackendSetup
+func BackendVerify(backend string, proof, vk any, publicWitness witness.Witness) error {
+	switch backend {
+	case "groth16":
+		return groth16.Verify(proof.(groth16.Proof), vk.(groth16.VerifyingKey), publicWitness)
+	case "plonk":
+		return plonk.Verify(proof.(plonk.Proof), vk.(plonk.VerifyingKey), publicWitness)
+	case "plonkFRI":
+		return plonkfri.Verify(proof.(plonkfri.Proof), vk.(plonkfri.VerifyingKey), publicWitness)
+	}
+	return fmt.Errorf("unknown backend %q", backend)
+}
+
+// empty constraint system of a backend to deserialize into
+func BackendCS(backend string, curveID ecc.ID) (constraint.ConstraintSystem, error) {
+	switch backend {
+	case "groth16":
+		return groth16.NewCS(curveID), nil
+	case "plonk", "plonkFRI":
+		return plonk.NewCS(curveID), nil
+	}
+	return nil, fmt.Errorf("unknown backend %q", backend)
+}
+
+// empty keys and proof of a backend to deserialize into, plonkFRI keys and proofs have no serialization
+func BackendKeys(backend string, curveID ecc.ID) (pk, vk, proof Key, err error) {
+	switch backend {
+	case "groth16":
+		return groth16.NewProvingKey(curveID), groth16.NewVerifyingKey(curveID), groth16.NewProof(curveID), nil
+	case "plonk":
+		return plonk.NewProvingKey(curveID), plonk.NewVerifyingKey(curveID), plonk.NewProof(curveID), nil
+	case "plonkFRI":
+		return nil, nil, nil, ErrNotStorable
+	}
+	return nil, nil, nil, fmt.Errorf("unknown backend %q", backend)
+}
+
+// reads a kzg srs in gnark-crypto serialization, e.g. converted from a powers of tau ceremony
+func LoadSRS(path string, curveID ecc.ID) (kzg.SRS, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	srs := kzg.NewSRS(curveID)
+	if _, err := srs.ReadFrom(f); err != nil {
+		return nil, fmt.Errorf("srs: %w", err)
+	}
+	return srs, nil
+}
+
+// kzg srs sized for the constraint system with a known toxic waste.
+// anyone can forge plonk proofs against keys derived from it, use it in tests and benchmarks only.
+func NewTestSRS(ccs constraint.ConstraintSystem) (kzg.SRS, error) {
+	return test.NewKZGSRS(ccs)
+}
+
+// artifacts and measurements of a ProofWithBackend run
+type ProofResult struct {
+	Backend string
+
+	// time measures, keys: compile, setup, prove, verify
+	Times map[string]time.Duration
+
+	// serialized byte sizes, keys: ccs, srs, pk, vk, proof, witness
+	Sizes map[string]int64
+
+	// constraint system statistics
+	NbConstraints       int
+	NbPublicVariables   int
+	NbSecretVariables   int
+	NbInternalVariables int
+
+	// groth16.Proof, plonk.Proof or plonkfri.Proof
+	Proof any
+	// groth16.VerifyingKey, plonk.VerifyingKey or plonkfri.VerifyingKey
+	VK            any
+	PublicWitness witness.Witness
+}
+
+// serialized byte size of obj
+func byteSize(obj io.WriterTo) (int64, error) {
+	return obj.WriteTo(io.Discard)
+}
+
 // non-gnark zk system evalaution functions
-func ProofWithBackend(backend string, compile bool, circuit frontend.Circuit, assignment frontend.Circuit, curveID ecc.ID) (map[string]time.Duration, error) {
+// plonk requires a kzg srs large enough for the circuit, see LoadSRS
+func ProofWithBackend(backend string, compile bool, circuit frontend.Circuit, assignment frontend.Circuit, curveID ecc.ID, srs kzg.SRS) (*ProofResult, error) {
 
-	// time measures
-	data := map[string]time.Duration{}
+	res := &ProofResult{
+		Backend: backend,
+		Times:   map[string]time.Duration{},
+		Sizes:   map[string]int64{},
+	}
 
 	// generate witness
 	witness, err := frontend.NewWitness(assignment, curveID.ScalarField())
@@ -48,15 +188,10 @@ func ProofWithBackend(backend string, compile bool, circuit frontend.Circuit, as
 	}
 
 	// init builders
-	var builder frontend.NewBuilder
-	var srs kzg.SRS
-	switch backend {
-	case "groth16":
-		builder = r1cs.NewBuilder
-	case "plonk":
-		builder = scs.NewBuilder
-	case "plonkFRI":
-		builder = scs.NewBuilder
+	builder, err := BackendBuilder(backend)
+	if err != nil {
+		log.Error().Msg("BackendBuilder")
+		return nil, err
 	}
 
 	// generate CompiledConstraintSystem
@@ -69,235 +204,130 @@ func ProofWithBackend(backend string, compile bool, circuit frontend.Circuit, as
 	elapsed := time.Since(start)
 	log.Debug().Str("elapsed", elapsed.String()).Msg("compile constraint system time.")
 
-	data["compile"] = elapsed
+	res.Times["compile"] = elapsed
+	res.setStats(ccs)
 
 	// measure byte size
-	var buf bytes.Buffer
-	bytesWritten, err := ccs.WriteTo(&buf)
+	res.Sizes["ccs"], err = byteSize(ccs)
 	if err != nil {
 		log.Error().Msg("ccs serialization error")
 		return nil, err
 	}
-	log.Debug().Str("written", strconv.FormatInt(bytesWritten, 10)).Msg("compiled constraint system bytes")
-
-	// kzg setup if using plonk
-	if backend == "plonk" {
-		srs, err = test.NewKZGSRS(ccs)
-		if err != nil {
-			log.Error().Msg("test.NewKZGSRS(ccs)")
-			return nil, err
-		}
+	log.Debug().Str("written", strconv.FormatInt(res.Sizes["ccs"], 10)).Msg("compiled constraint system bytes")
 
-		elapsed := time.Since(start)
-		data["compile"] = elapsed
-
-		// measure byte size
-		var bufExtra bytes.Buffer
-		bytesWritten, err := srs.WriteTo(&bufExtra)
+	// measure byte size of the kzg srs if using plonk
+	if backend == "plonk" && srs != nil {
+		res.Sizes["srs"], err = byteSize(srs)
 		if err != nil {
 			log.Error().Msg("srs serialization error")
 			return nil, err
 		}
-		log.Debug().Str("written srs", strconv.FormatInt(bytesWritten, 10)).Msg("compiled constraint system bytes")
-
+		log.Debug().Str("written srs", strconv.FormatInt(res.Sizes["srs"], 10)).Msg("srs bytes")
 	}
 
 	if compile {
-		return nil, err
+		return res, nil
 	}
 
-	// proof system execution
-	switch backend {
-	case "groth16":
-
-		// setup
-		start = time.Now()
-		pk, vk, err := groth16.Setup(ccs)
-		if err != nil {
-			log.Error().Msg("groth16.Setup")
-			return nil, err
-		}
-		elapsed = time.Since(start)
-		log.Debug().Str("elapsed", elapsed.String()).Msg("groth16.Setup time.")
-
-		data["setup"] = elapsed
-
-		// measure byte size
-		var buf1 bytes.Buffer
-		bytesWritten1, err := pk.WriteTo(&buf1)
-		if err != nil {
-			log.Error().Msg("ccs serialization error")
-			return nil, err
-		}
-		log.Debug().Str("written", strconv.FormatInt(bytesWritten1, 10)).Msg("prover key bytes")
-		var buf2 bytes.Buffer
-		bytesWritten2, err := vk.WriteTo(&buf2)
-		if err != nil {
-			log.Error().Msg("ccs serialization error")
-			return nil, err
-		}
-		log.Debug().Str("written", strconv.FormatInt(bytesWritten2, 10)).Msg("verifier key bytes")
-
-		// prove
-		start = time.Now()
-		proof, err := groth16.Prove(ccs, pk, witness)
-		if err != nil {
-			log.Error().Msg("groth16.Prove")
-			return nil, err
-		}
-		elapsed = time.Since(start)
-		log.Debug().Str("elapsed", elapsed.String()).Msg("groth16.Prove time.")
-
-		data["prove"] = elapsed
-
-		// measure bytes
-		var buf3 bytes.Buffer
-		bytesWritten3, err := proof.WriteTo(&buf3)
-		if err != nil {
-			log.Error().Msg("proof serialization error")
-			return nil, err
-		}
-		log.Debug().Str("written", strconv.FormatInt(bytesWritten3, 10)).Msg("proof bytes")
-
-		// generate public witness
-		publicWitness, _ := witness.Public()
-
-		// measure bytes
-		witnessBytes, err := publicWitness.MarshalBinary()
-		if err != nil {
-			log.Error().Msg("witness marshal binary error")
-			return nil, err
-		}
-		log.Debug().Str("written", strconv.Itoa(len(witnessBytes)/8)).Msg("witness bytes")
-
-		// verification
-		start = time.Now()
-		err = groth16.Verify(proof, vk, publicWitness)
-		if err != nil {
-			log.Error().Msg("groth16.Verify")
-			return nil, err
-		}
-		elapsed = time.Since(start)
-		log.Debug().Str("elapsed", elapsed.String()).Msg("groth16.Verify time.")
-
-		data["verify"] = elapsed
+	// generate public witness
+	publicWitness, err := witness.Public()
+	if err != nil {
+		log.Error().Msg("witness.Public")
+		return nil, err
+	}
+	res.PublicWitness = publicWitness
 
-	case "plonk":
+	// measure bytes
+	witnessBytes, err := publicWitness.MarshalBinary()
+	if err != nil {
+		log.Error().Msg("witness marshal binary error")
+		return nil, err
+	}
+	res.Sizes["witness"] = int64(len(witnessBytes))
+	log.Debug().Str("written", strconv.Itoa(len(witnessBytes))).Msg("witness bytes")
 
-		// setup
-		start = time.Now()
-		pk, vk, err := plonk.Setup(ccs, srs)
-		if err != nil {
-			log.Error().Msg("plonk.Setup")
-			return nil, err
-		}
-		elapsed = time.Since(start)
-		log.Debug().Str("elapsed", elapsed.String()).Msg("plonk.Setup time.")
+	// setup
+	start = time.Now()
+	pk, vk, err := BackendSetup(backend, ccs, srs)
+	if err != nil {
+		log.Error().Msg(backend + " setup")
+		return nil, err
+	}
+	elapsed = time.Since(start)
+	log.Debug().Str("elapsed", elapsed.String()).Msg(backend + " setup time.")
 
-		data["setup"] = elapsed
+	res.Times["setup"] = elapsed
+	res.VK = vk
 
-		// measure byte size
-		var buf1 bytes.Buffer
-		bytesWritten1, err := pk.WriteTo(&buf1)
-		if err != nil {
-			log.Error().Msg("ccs serialization error")
-			return nil, err
-		}
-		log.Debug().Str("written", strconv.FormatInt(bytesWritten1, 10)).Msg("prover key bytes")
-		var buf2 bytes.Buffer
-		bytesWritten2, err := vk.WriteTo(&buf2)
-		if err != nil {
-			log.Error().Msg("ccs serialization error")
+	// measure byte size, plonkFRI keys and proofs have no serialization
+	pkw, okPK := pk.(io.WriterTo)
+	vkw, okVK := vk.(io.WriterTo)
+	if okPK && okVK {
+		if err := res.measureKeys(pkw, vkw); err != nil {
 			return nil, err
 		}
-		log.Debug().Str("written", strconv.FormatInt(bytesWritten2, 10)).Msg("verifier key bytes")
+	}
 
-		// prove
-		start = time.Now()
-		proof, err := plonk.Prove(ccs, pk, witness)
-		if err != nil {
-			log.Error().Msg("plonk.Prove")
-			return nil, err
-		}
-		elapsed = time.Since(start)
-		log.Debug().Str("elapsed", elapsed.String()).Msg("plonk.Prove time.")
+	// prove
+	start = time.Now()
+	proof, err := BackendProve(backend, ccs, pk, witness)
+	if err != nil {
+		log.Error().Msg(backend + " prove")
+		return nil, err
+	}
+	elapsed = time.Since(start)
+	log.Debug().Str("elapsed", elapsed.String()).Msg(backend + " prove time.")
 
-		data["prove"] = elapsed
+	res.Times["prove"] = elapsed
+	res.Proof = proof
 
-		// measure bytes
-		var buf3 bytes.Buffer
-		bytesWritten3, err := proof.WriteTo(&buf3)
+	// measure bytes
+	if w, ok := proof.(io.WriterTo); ok {
+		res.Sizes["proof"], err = byteSize(w)
 		if err != nil {
 			log.Error().Msg("proof serialization error")
 			return nil, err
 		}
-		log.Debug().Str("written", strconv.FormatInt(bytesWritten3, 10)).Msg("proof bytes")
-
-		// generate public witness
-		publicWitness, _ := witness.Public()
-
-		// measure bytes
-		witnessBytes, err := publicWitness.MarshalBinary()
-		if err != nil {
-			log.Error().Msg("witness marshal binary error")
-			return nil, err
-		}
-		// fmt.Println("witnessBytes:", witnessBytes)
-		log.Debug().Str("written", strconv.Itoa(len(witnessBytes)/8)).Msg("witness bytes")
-
-		// verify
-		start = time.Now()
-		err = plonk.Verify(proof, vk, publicWitness)
-		if err != nil {
-			log.Error().Msg("plonk.Verify")
-			return nil, err
-		}
-		elapsed = time.Since(start)
-		log.Debug().Str("elapsed", elapsed.String()).Msg("plonk.Verify time.")
-
-		data["verify"] = elapsed
-
-	case "plonkFRI":
-
-		// setup
-		start = time.Now()
-		pk, vk, err := plonkfri.Setup(ccs)
-		if err != nil {
-			log.Error().Msg("plonkfri.Setup")
-			return nil, err
-		}
-		elapsed = time.Since(start)
-		log.Debug().Str("elapsed", elapsed.String()).Msg("plonkfri.Setup time.")
-
-		data["setup"] = elapsed
+		log.Debug().Str("written", strconv.FormatInt(res.Sizes["proof"], 10)).Msg("proof bytes")
+	}
 
-		// prove
-		start = time.Now()
-		correctProof, err := plonkfri.Prove(ccs, pk, witness)
-		if err != nil {
-			log.Error().Msg("plonkfri.Prove")
-			return nil, err
-		}
-		elapsed = time.Since(start)
-		log.Debug().Str("elapsed", elapsed.String()).Msg("plonkfri.Prove time.")
+	// verify
+	start = time.Now()
+	err = BackendVerify(backend, proof, vk, publicWitness)
+	if err != nil {
+		log.Error().Msg(backend + " verify")
+		return nil, err
+	}
+	elapsed = time.Since(start)
+	log.Debug().Str("elapsed", elapsed.String()).Msg(backend + " verify time.")
 
-		data["prove"] = elapsed
+	res.Times["verify"] = elapsed
 
-		// generate public witness
-		publicWitness, _ := witness.Public()
+	return res, nil
+}
 
-		// verify
-		start = time.Now()
-		err = plonkfri.Verify(correctProof, vk, publicWitness)
-		if err != nil {
-			log.Error().Msg("plonkfri.Verify")
-			return nil, err
-		}
-		elapsed = time.Since(start)
-		log.Debug().Str("elapsed", elapsed.String()).Msg("plonkfri.Verify time.")
+// constraint system statistics
+func (res *ProofResult) setStats(ccs constraint.ConstraintSystem) {
+	res.NbConstraints = ccs.GetNbConstraints()
+	res.NbPublicVariables = ccs.GetNbPublicVariables()
+	res.NbSecretVariables = ccs.GetNbSecretVariables()
+	res.NbInternalVariables = ccs.GetNbInternalVariables()
+}
 
-		data["verify"] = elapsed
+// prover and verifier key byte sizes
+func (res *ProofResult) measureKeys(pk, vk io.WriterTo) error {
+	var err error
+	res.Sizes["pk"], err = byteSize(pk)
+	if err != nil {
+		log.Error().Msg("pk serialization error")
+		return err
 	}
-
-	return data, nil
+	log.Debug().Str("written", strconv.FormatInt(res.Sizes["pk"], 10)).Msg("prover key bytes")
+	res.Sizes["vk"], err = byteSize(vk)
+	if err != nil {
+		log.Error().Msg("vk serialization error")
+		return err
+	}
+	log.Debug().Str("written", strconv.FormatInt(res.Sizes["vk"], 10)).Msg("verifier key bytes")
+	return nil
 }
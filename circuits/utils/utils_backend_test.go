@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProofWithBackendCompile(t *testing.T) {
+	res, err := ProofWithBackend("plonk", true, &powCircuit{exponent: 3}, &powCircuit{X: 2, Y: 8}, ecc.BN254, testSRS(t, "plonk", compilePow(t, "plonk", 3)))
+	require.NoError(t, err)
+
+	require.NotZero(t, res.NbConstraints)
+	require.Equal(t, 1, res.NbSecretVariables)
+	require.NotZero(t, res.Sizes["ccs"])
+	require.NotZero(t, res.Sizes["srs"])
+	require.Nil(t, res.Proof)
+}
+
+func TestProofWithBackendResult(t *testing.T) {
+	for _, backend := range []string{"groth16", "plonk", "plonkFRI"} {
+		t.Run(backend, func(t *testing.T) {
+			// gnark plonkfri parallelizes over runtime.NumCPU()/2 tasks
+			if backend == "plonkFRI" && runtime.NumCPU() < 2 {
+				t.Skip("plonkFRI requires at least two cpus")
+			}
+			res, err := ProofWithBackend(backend, false, &powCircuit{exponent: 3}, &powCircuit{X: 2, Y: 8}, ecc.BN254, testSRS(t, backend, compilePow(t, backend, 3)))
+			require.NoError(t, err)
+
+			require.NotNil(t, res.Proof)
+			require.NotNil(t, res.VK)
+			require.NotNil(t, res.PublicWitness)
+			for _, k := range []string{"compile", "setup", "prove", "verify"} {
+				require.Contains(t, res.Times, k)
+			}
+			if backend != "plonkFRI" {
+				for _, k := range []string{"ccs", "pk", "vk", "proof", "witness"} {
+					require.NotZero(t, res.Sizes[k], k)
+				}
+			}
+
+			data := map[string]string{}
+			AddStats(data, []map[string]time.Duration{res.Times}, false)
+			AddSizes(data, res)
+			require.Equal(t, backend, data["backend"])
+			require.Contains(t, data, "time_prove_mean")
+			require.Contains(t, data, "bytes_ccs")
+		})
+	}
+}
@@ -18,6 +18,7 @@ package utils
 
 import (
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/rangecheck"
 )
 
 // this function expects encoding of 2hex/1byte per frontend.Variable
@@ -57,6 +58,20 @@ func OpadConcat(api frontend.API, inp1 [32]frontend.Variable, inp2 [32]frontend.
 	return dHSopadConcatMSin
 }
 
+// inp1 xor opad and concatenates with inp2, sha384 block size
+func OpadConcat384(api frontend.API, inp1 [48]frontend.Variable, inp2 [48]frontend.Variable) []frontend.Variable {
+	out := make([]frontend.Variable, 128+48)
+	for i := 0; i < 128; i++ {
+		var k frontend.Variable = 0
+		if i < 48 {
+			k = inp1[i]
+		}
+		out[i] = VariableXor(api, k, frontend.Variable(0x5c), 8)
+	}
+	copy(out[128:], inp2[:])
+	return out
+}
+
 // adjustable bitwise xor operation on frontend.Variables
 func VariableXor(api frontend.API, a frontend.Variable, b frontend.Variable, size int) frontend.Variable {
 	bitsA := api.ToBinary(a, size)
@@ -84,3 +99,30 @@ func ZeroPadOpad(api frontend.API, inp [32]frontend.Variable) [64]frontend.Varia
 	}
 	return res
 }
+
+// mask[i] = 1 for i < length and 0 otherwise, asserts 0 <= length <= n
+func PrefixMask(api frontend.API, length frontend.Variable, n int) []frontend.Variable {
+	mask := make([]frontend.Variable, n)
+	active := frontend.Variable(1)
+	boundaries := frontend.Variable(0)
+	for i := 0; i < n; i++ {
+		atEnd := api.IsZero(api.Sub(length, i))
+		boundaries = api.Add(boundaries, atEnd)
+		active = api.Sub(active, atEnd)
+		mask[i] = active
+	}
+	// exactly one position in [0, n] equals length
+	boundaries = api.Add(boundaries, api.IsZero(api.Sub(length, n)))
+	api.AssertIsEqual(boundaries, 1)
+	return mask
+}
+
+// asserts 0 <= b < 256 for every input, all checks of a circuit share one lookup based range checker
+func AssertIsBytes(api frontend.API, in ...[]frontend.Variable) {
+	rc := rangecheck.New(api)
+	for _, bytes := range in {
+		for _, b := range bytes {
+			rc.Check(b, 8)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/require"
+)
+
+type bytesCircuit struct {
+	In  [4]frontend.Variable
+	Sum frontend.Variable `gnark:",public"`
+}
+
+func (circuit *bytesCircuit) Define(api frontend.API) error {
+	AssertIsBytes(api, circuit.In[:2], circuit.In[2:])
+	api.AssertIsEqual(api.Add(circuit.In[0], circuit.In[1], circuit.In[2], circuit.In[3]), circuit.Sum)
+	return nil
+}
+
+// range check only, a failure can come from AssertIsBytes alone
+type rangeCheckCircuit struct {
+	In [4]frontend.Variable
+}
+
+func (circuit *rangeCheckCircuit) Define(api frontend.API) error {
+	AssertIsBytes(api, circuit.In[:2], circuit.In[2:])
+	return nil
+}
+
+func TestAssertIsBytes(t *testing.T) {
+	var circuit bytesCircuit
+
+	valid := bytesCircuit{In: [4]frontend.Variable{0, 1, 254, 255}, Sum: 510}
+	require.NoError(t, test.IsSolved(&circuit, &valid, ecc.BN254.ScalarField()))
+
+	// same sum with out of range field elements
+	for _, in := range [][4]frontend.Variable{{256, 0, 254, 0}, {-1, 2, 254, 255}} {
+		invalid := bytesCircuit{In: in, Sum: 510}
+		require.Error(t, test.IsSolved(&circuit, &invalid, ecc.BN254.ScalarField()), in)
+	}
+
+	// the range check alone rejects every out of range input
+	require.NoError(t, test.IsSolved(&rangeCheckCircuit{}, &rangeCheckCircuit{In: valid.In}, ecc.BN254.ScalarField()))
+	for i := range valid.In {
+		for _, v := range []frontend.Variable{256, -1} {
+			in := valid.In
+			in[i] = v
+			require.Error(t, test.IsSolved(&rangeCheckCircuit{}, &rangeCheckCircuit{In: in}, ecc.BN254.ScalarField()), in)
+		}
+	}
+
+	// the lookup argument commits in both backends
+	for _, backend := range []string{"groth16", "plonk"} {
+		builder, err := BackendBuilder(backend)
+		require.NoError(t, err)
+		ccs, err := frontend.Compile(ecc.BN254.ScalarField(), builder, &circuit)
+		require.NoError(t, err)
+		_, err = ProofWithBackend(backend, false, &circuit, &valid, ecc.BN254, testSRS(t, backend, ccs))
+		require.NoError(t, err, backend)
+	}
+}
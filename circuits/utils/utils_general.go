@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math/rand"
 	"os"
+	"strconv"
 	"testing"
 	"time"
 
@@ -37,6 +38,24 @@ func PadSha256(len uint64) []byte {
 	return padlen
 }
 
+// non-gnark padding function, sha384 and sha512
+func PadSha512(len uint64) []byte {
+	var tmp [128 + 16]byte // padding + length buffer
+	tmp[0] = 0x80
+	var t uint64
+	if len%128 < 112 {
+		t = 112 - len%128
+	} else {
+		t = 128 + 112 - len%128
+	}
+
+	// Length in bits, upper 64 bits are zero.
+	len <<= 3
+	padlen := tmp[:t+16]
+	binary.BigEndian.PutUint64(padlen[t+8:], len)
+	return padlen
+}
+
 // non-gnark str to int conversion
 func StrToIntSlice(inputData string, hexRepresentation bool) []int {
 
@@ -76,6 +95,23 @@ func StoreM(jsonData map[string]string, path string, filename string) error {
 	return nil
 }
 
+// reads a json file written by StoreM
+func LoadM(path string, filename string) (map[string]string, error) {
+
+	file, err := os.ReadFile(path + filename + ".json")
+	if err != nil {
+		log.Error().Err(err).Msg("os.ReadFile")
+		return nil, err
+	}
+	jsonData := map[string]string{}
+	err = json.Unmarshal(file, &jsonData)
+	if err != nil {
+		log.Error().Err(err).Msg("json.Unmarshal")
+		return nil, err
+	}
+	return jsonData, nil
+}
+
 func AddStats(data map[string]string, results []map[string]time.Duration, print2console bool) {
 
 	// default type expected by github.com/montanaflynn/stats package
@@ -132,6 +168,21 @@ func AddStats(data map[string]string, results []map[string]time.Duration, print2
 	data["time_verify_standard_deviation"] = fmt.Sprintf("%.3f", std)
 }
 
+// adds constraint system statistics and serialized byte sizes of a ProofWithBackend run
+func AddSizes(data map[string]string, result *ProofResult) {
+
+	data["backend"] = result.Backend
+	data["constraints"] = strconv.Itoa(result.NbConstraints)
+	data["public_variables"] = strconv.Itoa(result.NbPublicVariables)
+	data["secret_variables"] = strconv.Itoa(result.NbSecretVariables)
+	data["internal_variables"] = strconv.Itoa(result.NbInternalVariables)
+
+	// keys missing for the backend, e.g. srs for groth16, are omitted
+	for k, v := range result.Sizes {
+		data["bytes_"+k] = strconv.FormatInt(v, 10)
+	}
+}
+
 // compressThreshold --> if linear expressions are larger than this, the frontend will introduce
 // intermediate constraints. The lower this number is, the faster compile time should be (to a point)
 // but resulting circuit will have more constraints (slower proving time).
@@ -0,0 +1,310 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/constraint"
+)
+
+// file names of a key store entry
+const (
+	ccsFile         = "circuit.ccs"
+	pkFile          = "proving.key"
+	vkFile          = "verifying.key"
+	srsFile         = "srs.kzg"
+	fingerprintFile = "fingerprint"
+)
+
+var (
+	ErrKeyNotFound = errors.New("keystore: no keys stored")
+	ErrStaleKey    = errors.New("keystore: stored keys belong to a different circuit")
+	ErrCorruptKey  = errors.New("keystore: stored files differ from the files written by the setup")
+	ErrNotStorable = errors.New("keystore: plonkFRI keys cannot be serialized")
+)
+
+// serializable proving or verifying key of the groth16 and plonk backends
+type Key interface {
+	io.WriterTo
+	io.ReaderFrom
+}
+
+// constraint system and setup output of one circuit
+type ProofKeys struct {
+	Fingerprint string
+	CCS         constraint.ConstraintSystem
+	PK          Key     // groth16.ProvingKey or plonk.ProvingKey
+	VK          Key     // groth16.VerifyingKey or plonk.VerifyingKey
+	SRS         kzg.SRS // plonk only
+}
+
+// stores constraint systems and keys on disk, one directory per circuit name and backend.
+// the fingerprint file holds the circuit fingerprint and a sha256 over the stored ccs, key and srs files.
+// the file hash is stored next to the files, it detects corrupt or partially written entries, not tampering.
+type KeyStore struct {
+	dir     string
+	backend string
+	curveID ecc.ID
+}
+
+func NewKeyStore(dir, backend string, curveID ecc.ID) KeyStore {
+	return KeyStore{dir: dir, backend: backend, curveID: curveID}
+}
+
+// sha256 over the serialized constraint system
+func CircuitFingerprint(ccs constraint.ConstraintSystem) (string, error) {
+	h := sha256.New()
+	if _, err := ccs.WriteTo(h); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// runs the backend setup on a compiled constraint system, plonk requires a kzg srs
+func SetupWithBackend(backend string, ccs constraint.ConstraintSystem, srs kzg.SRS) (ProofKeys, error) {
+
+	if backend == "plonkFRI" {
+		return ProofKeys{}, ErrNotStorable
+	}
+
+	pk, vk, err := BackendSetup(backend, ccs, srs)
+	if err != nil {
+		log.Error().Msg(backend + " setup")
+		return ProofKeys{}, err
+	}
+
+	fingerprint, err := CircuitFingerprint(ccs)
+	if err != nil {
+		return ProofKeys{}, err
+	}
+	keys := ProofKeys{Fingerprint: fingerprint, CCS: ccs, PK: pk.(Key), VK: vk.(Key)}
+	if backend == "plonk" {
+		keys.SRS = srs
+	}
+
+	return keys, nil
+}
+
+func (ks *KeyStore) path(name, file string) string {
+	return filepath.Join(ks.dir, name, ks.backend, file)
+}
+
+// writes the constraint system and returns its fingerprint
+func (ks *KeyStore) SaveCCS(name string, ccs constraint.ConstraintSystem) (string, error) {
+	if err := os.MkdirAll(filepath.Join(ks.dir, name, ks.backend), 0755); err != nil {
+		return "", err
+	}
+	if err := ks.write(name, ccsFile, ccs); err != nil {
+		return "", err
+	}
+	return CircuitFingerprint(ccs)
+}
+
+// reads the constraint system stored under name
+func (ks *KeyStore) LoadCCS(name string) (constraint.ConstraintSystem, error) {
+	ccs, err := BackendCS(ks.backend, ks.curveID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ks.read(name, ccsFile, ccs); err != nil {
+		return nil, err
+	}
+	return ccs, nil
+}
+
+// writes constraint system, keys, srs and the circuit fingerprint
+func (ks *KeyStore) Save(name string, keys ProofKeys) error {
+
+	if ks.backend == "plonkFRI" {
+		return ErrNotStorable
+	}
+
+	fingerprint, err := ks.SaveCCS(name, keys.CCS)
+	if err != nil {
+		return err
+	}
+	if keys.Fingerprint != "" && keys.Fingerprint != fingerprint {
+		return ErrStaleKey
+	}
+
+	if err := ks.write(name, pkFile, keys.PK); err != nil {
+		return err
+	}
+	if err := ks.write(name, vkFile, keys.VK); err != nil {
+		return err
+	}
+	if keys.SRS != nil {
+		if err := ks.write(name, srsFile, keys.SRS); err != nil {
+			return err
+		}
+	}
+
+	filesHash, err := ks.hashFiles(name)
+	if err != nil {
+		return err
+	}
+
+	// fingerprint last, an entry without it is incomplete
+	return os.WriteFile(ks.path(name, fingerprintFile), []byte(fingerprint+"\n"+filesHash), 0644)
+}
+
+// reads the keys stored under name, ccs is the freshly compiled circuit the keys must belong to.
+// if ccs is nil, the stored constraint system is used.
+func (ks *KeyStore) Load(name string, ccs constraint.ConstraintSystem) (ProofKeys, error) {
+
+	if ks.backend == "plonkFRI" {
+		return ProofKeys{}, ErrNotStorable
+	}
+
+	stored, err := os.ReadFile(ks.path(name, fingerprintFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return ProofKeys{}, ErrKeyNotFound
+	}
+	if err != nil {
+		return ProofKeys{}, err
+	}
+	storedCircuit, storedFiles, ok := bytes.Cut(stored, []byte("\n"))
+	if !ok {
+		return ProofKeys{}, ErrStaleKey
+	}
+
+	// files are checked before any of them is parsed
+	filesHash, err := ks.hashFiles(name)
+	if err != nil {
+		return ProofKeys{}, err
+	}
+	if !bytes.Equal(storedFiles, []byte(filesHash)) {
+		return ProofKeys{}, ErrCorruptKey
+	}
+
+	if ccs == nil {
+		ccs, err = ks.LoadCCS(name)
+		if err != nil {
+			return ProofKeys{}, err
+		}
+	}
+	fingerprint, err := CircuitFingerprint(ccs)
+	if err != nil {
+		return ProofKeys{}, err
+	}
+	if !bytes.Equal(storedCircuit, []byte(fingerprint)) {
+		log.Debug().Str("stored", string(storedCircuit)).Str("compiled", fingerprint).Msg("fingerprint mismatch")
+		return ProofKeys{}, ErrStaleKey
+	}
+
+	keys := ProofKeys{Fingerprint: fingerprint, CCS: ccs}
+	keys.PK, keys.VK, _, err = BackendKeys(ks.backend, ks.curveID)
+	if err != nil {
+		return ProofKeys{}, err
+	}
+	if ks.backend == "plonk" {
+		keys.SRS = kzg.NewSRS(ks.curveID)
+		if err := ks.read(name, srsFile, keys.SRS); err != nil {
+			return ProofKeys{}, err
+		}
+	}
+	if err := ks.read(name, pkFile, keys.PK); err != nil {
+		return ProofKeys{}, err
+	}
+	if err := ks.read(name, vkFile, keys.VK); err != nil {
+		return ProofKeys{}, err
+	}
+
+	return keys, nil
+}
+
+// loads the keys of the compiled circuit, runs and stores a new setup if none or stale keys are stored
+// srs is only used by a new plonk setup
+func (ks *KeyStore) LoadOrSetup(name string, ccs constraint.ConstraintSystem, srs kzg.SRS) (ProofKeys, error) {
+
+	keys, err := ks.Load(name, ccs)
+	if err == nil {
+		return keys, nil
+	}
+	if !errors.Is(err, ErrKeyNotFound) && !errors.Is(err, ErrStaleKey) {
+		return ProofKeys{}, err
+	}
+	log.Debug().Str("name", name).Err(err).Msg("running setup")
+
+	keys, err = SetupWithBackend(ks.backend, ccs, srs)
+	if err != nil {
+		return ProofKeys{}, err
+	}
+	return keys, ks.Save(name, keys)
+}
+
+// sha256 over the stored ccs, key and srs files of an entry
+func (ks *KeyStore) hashFiles(name string) (string, error) {
+	files := []string{ccsFile, pkFile, vkFile}
+	if ks.backend == "plonk" {
+		files = append(files, srsFile)
+	}
+
+	h := sha256.New()
+	for _, file := range files {
+		f, err := os.Open(ks.path(name, file))
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (ks *KeyStore) write(name, file string, obj io.WriterTo) error {
+	f, err := os.Create(ks.path(name, file))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := obj.WriteTo(f)
+	if err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	log.Debug().Int64("written", n).Str("file", file).Msg("keystore bytes")
+	return nil
+}
+
+func (ks *KeyStore) read(name, file string, obj io.ReaderFrom) error {
+	f, err := os.Open(ks.path(name, file))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := obj.ReadFrom(f); err != nil {
+		return fmt.Errorf("%s: %w", file, err)
+	}
+	return nil
+}
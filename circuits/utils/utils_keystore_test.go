@@ -0,0 +1,177 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/stretchr/testify/require"
+)
+
+// x^e == y, the exponent changes the circuit shape
+type powCircuit struct {
+	exponent int
+	X        frontend.Variable
+	Y        frontend.Variable `gnark:",public"`
+}
+
+func (circuit *powCircuit) Define(api frontend.API) error {
+	res := frontend.Variable(1)
+	for i := 0; i < circuit.exponent; i++ {
+		res = api.Mul(res, circuit.X)
+	}
+	api.AssertIsEqual(res, circuit.Y)
+	return nil
+}
+
+func compilePow(t *testing.T, backend string, exponent int) constraint.ConstraintSystem {
+	builder, err := BackendBuilder(backend)
+	require.NoError(t, err)
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), builder, &powCircuit{exponent: exponent})
+	require.NoError(t, err)
+	return ccs
+}
+
+// insecure srs of the plonk backend, nil for the other backends
+func testSRS(t *testing.T, backend string, ccs constraint.ConstraintSystem) kzg.SRS {
+	if backend != "plonk" {
+		return nil
+	}
+	srs, err := NewTestSRS(ccs)
+	require.NoError(t, err)
+	return srs
+}
+
+func TestCircuitFingerprint(t *testing.T) {
+	f1, err := CircuitFingerprint(compilePow(t, "groth16", 3))
+	require.NoError(t, err)
+	f2, err := CircuitFingerprint(compilePow(t, "groth16", 3))
+	require.NoError(t, err)
+	f3, err := CircuitFingerprint(compilePow(t, "groth16", 4))
+	require.NoError(t, err)
+
+	require.Equal(t, f1, f2)
+	require.NotEqual(t, f1, f3)
+}
+
+func TestKeyStore(t *testing.T) {
+	for _, backend := range []string{"groth16", "plonk"} {
+		t.Run(backend, func(t *testing.T) {
+			ks := NewKeyStore(t.TempDir(), backend, ecc.BN254)
+			ccs := compilePow(t, backend, 3)
+
+			// empty store
+			_, err := ks.Load("pow", ccs)
+			require.True(t, errors.Is(err, ErrKeyNotFound))
+
+			keys, err := SetupWithBackend(backend, ccs, testSRS(t, backend, ccs))
+			require.NoError(t, err)
+			require.NoError(t, ks.Save("pow", keys))
+
+			// reload against freshly compiled circuit and prove
+			loaded, err := ks.Load("pow", compilePow(t, backend, 3))
+			require.NoError(t, err)
+			require.Equal(t, keys.Fingerprint, loaded.Fingerprint)
+
+			witness, err := frontend.NewWitness(&powCircuit{X: 2, Y: 8}, ecc.BN254.ScalarField())
+			require.NoError(t, err)
+			publicWitness, err := witness.Public()
+			require.NoError(t, err)
+			switch backend {
+			case "groth16":
+				proof, err := groth16.Prove(loaded.CCS, loaded.PK.(groth16.ProvingKey), witness)
+				require.NoError(t, err)
+				require.NoError(t, groth16.Verify(proof, loaded.VK.(groth16.VerifyingKey), publicWitness))
+			case "plonk":
+				proof, err := plonk.Prove(loaded.CCS, loaded.PK.(plonk.ProvingKey), witness)
+				require.NoError(t, err)
+				require.NoError(t, plonk.Verify(proof, loaded.VK.(plonk.VerifyingKey), publicWitness))
+			}
+
+			// stored ccs is used if none is given
+			_, err = ks.Load("pow", nil)
+			require.NoError(t, err)
+
+			// circuit changed after setup
+			stale := compilePow(t, backend, 4)
+			_, err = ks.Load("pow", stale)
+			require.True(t, errors.Is(err, ErrStaleKey))
+
+			// stale keys are replaced
+			_, err = ks.LoadOrSetup("pow", stale, testSRS(t, backend, stale))
+			require.NoError(t, err)
+			_, err = ks.Load("pow", stale)
+			require.NoError(t, err)
+		})
+	}
+}
+
+// keys replaced or corrupted on disk after the setup
+func TestKeyStoreCorruptKey(t *testing.T) {
+	for _, backend := range []string{"groth16", "plonk"} {
+		t.Run(backend, func(t *testing.T) {
+			ks := NewKeyStore(t.TempDir(), backend, ecc.BN254)
+			ccs := compilePow(t, backend, 3)
+			_, err := ks.LoadOrSetup("pow", ccs, testSRS(t, backend, ccs))
+			require.NoError(t, err)
+
+			// verifying key of another circuit
+			path := ks.path("pow", vkFile)
+			original, err := os.ReadFile(path)
+			require.NoError(t, err)
+			other := NewKeyStore(t.TempDir(), backend, ecc.BN254)
+			otherCCS := compilePow(t, backend, 4)
+			_, err = other.LoadOrSetup("pow", otherCCS, testSRS(t, backend, otherCCS))
+			require.NoError(t, err)
+			replaced, err := os.ReadFile(other.path("pow", vkFile))
+			require.NoError(t, err)
+			require.NoError(t, os.WriteFile(path, replaced, 0644))
+
+			_, err = ks.Load("pow", ccs)
+			require.True(t, errors.Is(err, ErrCorruptKey))
+
+			// corrupt keys are not replaced by a new setup
+			_, err = ks.LoadOrSetup("pow", ccs, testSRS(t, backend, ccs))
+			require.True(t, errors.Is(err, ErrCorruptKey))
+
+			// single flipped byte
+			original[len(original)-1] ^= 1
+			require.NoError(t, os.WriteFile(path, original, 0644))
+			_, err = ks.Load("pow", ccs)
+			require.True(t, errors.Is(err, ErrCorruptKey))
+		})
+	}
+}
+
+func TestKeyStorePlonkFRI(t *testing.T) {
+	ks := NewKeyStore(t.TempDir(), "plonkFRI", ecc.BN254)
+	_, err := ks.LoadOrSetup("pow", compilePow(t, "plonkFRI", 3), nil)
+	require.True(t, errors.Is(err, ErrNotStorable))
+}
+
+func TestSetupRequiresSRS(t *testing.T) {
+	ccs := compilePow(t, "plonk", 3)
+	_, err := SetupWithBackend("plonk", ccs, nil)
+	require.True(t, errors.Is(err, ErrMissingSRS))
+
+	// srs read back from disk
+	srs := testSRS(t, "plonk", ccs)
+	path := filepath.Join(t.TempDir(), "srs.kzg")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	_, err = srs.WriteTo(f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	loaded, err := LoadSRS(path, ecc.BN254)
+	require.NoError(t, err)
+	_, err = SetupWithBackend("plonk", ccs, loaded)
+	require.NoError(t, err)
+}
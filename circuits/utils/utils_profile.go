@@ -0,0 +1,124 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/profile"
+)
+
+// constraints per named stage of a circuit, counted while the circuit is compiled
+// all methods accept a nil receiver, gadgets call them unconditionally and count nothing without stages
+type Stages struct {
+	names   []string
+	counts  map[string]int
+	current string
+	session *profile.Profile
+}
+
+func NewStages() *Stages {
+	return &Stages{counts: map[string]int{}}
+}
+
+// ends the current stage and counts the following constraints for name
+// a stage entered more than once accumulates its counts
+func (s *Stages) Begin(name string) {
+	if s == nil {
+		return
+	}
+	s.End()
+	if _, ok := s.counts[name]; !ok {
+		s.names = append(s.names, name)
+		s.counts[name] = 0
+	}
+	s.current = name
+	s.session = profile.Start(profile.WithNoOutput())
+}
+
+// ends the current stage
+func (s *Stages) End() {
+	if s == nil || s.session == nil {
+		return
+	}
+	s.session.Stop()
+	s.counts[s.current] += s.session.NbConstraints()
+	s.session = nil
+	s.current = ""
+}
+
+// stage names in the order they were entered
+func (s *Stages) Names() []string {
+	if s == nil {
+		return nil
+	}
+	return s.names
+}
+
+// constraints of the stage
+func (s *Stages) Count(name string) int {
+	if s == nil {
+		return 0
+	}
+	return s.counts[name]
+}
+
+// constraints per stage with their share of total, constraints outside of all stages are listed as other
+// e.g. lookup tables and range checks committed at the end of compilation
+func (s *Stages) Report(total int) string {
+
+	var b strings.Builder
+	line := func(name string, n int) {
+		share := 0.0
+		if total > 0 {
+			share = 100 * float64(n) / float64(total)
+		}
+		fmt.Fprintf(&b, "%-12s %10d %6.2f%%\n", name, n, share)
+	}
+
+	sum := 0
+	for _, name := range s.Names() {
+		line(name, s.Count(name))
+		sum += s.Count(name)
+	}
+	line("other", total-sum)
+	line("total", total)
+
+	return b.String()
+}
+
+// compiles the circuit for the backend and returns the breakdown of the stages the circuit counts into
+// the pprof profile of all constraints is written to path, an empty path writes no profile
+func ProfileWithBackend(backend string, circuit frontend.Circuit, stages *Stages, path string) (string, error) {
+
+	builder, err := BackendBuilder(backend)
+	if err != nil {
+		return "", err
+	}
+
+	p := profile.Start(profile.WithPath(path))
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), builder, circuit)
+	p.Stop()
+	if err != nil {
+		return "", err
+	}
+
+	return stages.Report(ccs.GetNbConstraints()), nil
+}
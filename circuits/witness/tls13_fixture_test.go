@@ -0,0 +1,197 @@
+package witness
+
+import (
+	"strings"
+	"testing"
+
+	origo "circuits/origo"
+	record "circuits/record"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+func TestFixture(t *testing.T) {
+
+	body := []byte(`{"data":{"currency":"EUR","amount":{"value":"38002.20"}},"status":"ok"}`)
+	fixture, err := newFixture(fixtureConfig{Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixture.Records) != 1 || string(fixture.Plaintexts[0][:len(body)]) != string(body) {
+		t.Fatalf("unexpected records: %d", len(fixture.Records))
+	}
+
+	params, err := fixture.Params(0, "value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.SequenceNumber != "0000000000000000" || params.Substring != `"value"` {
+		t.Fatalf("unexpected parameters: %+v", params)
+	}
+}
+
+// Test for Solving, value of interest in a later record of a multi record response
+func TestFixtureSolving(t *testing.T) {
+
+	body := strings.Repeat(`{"padding":"xxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"},`, 6) + `{"amount":{"value":"51234.99"},"status":"ok"}`
+	fixture, err := newFixture(fixtureConfig{Body: []byte(body), RecordSize: 128})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seq := uint64(len(fixture.Records) - 1)
+	if seq == 0 {
+		t.Fatal("expected several records")
+	}
+
+	params, err := fixture.Params(seq, "value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	circuit, assignment, err := origo.NewTls13OracleWrapperFromParams(params, 51233)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// Test for Solving of a TLS_CHACHA20_POLY1305_SHA256 session with the poly1305 tag verified in-circuit
+func TestFixtureChaCha20Solving(t *testing.T) {
+
+	body := []byte(`{"data":{"currency":"EUR","amount":{"value":"38002.20"}},"status":"ok"}`)
+	fixture, err := newFixture(fixtureConfig{Body: body, CipherSuite: record.TLS_CHACHA20_POLY1305_SHA256})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params, err := fixture.Params(0, "value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	circuit, assignment, err := origo.NewTls13OracleSuiteWrapperFromParams(params, 38001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// tampered ciphertext outside of the selected chunks
+	tampered := assignment
+	tampered.Ciphertext = append([]frontend.Variable{}, assignment.Ciphertext...)
+	last := len(tampered.Ciphertext) - 1
+	tampered.Ciphertext[last] = (tampered.Ciphertext[last].(int) + 1) % 256
+	if err := test.IsSolved(&circuit, &tampered, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected tampered ciphertext to fail")
+	}
+
+	// value below threshold
+	_, assignment, _ = origo.NewTls13OracleSuiteWrapperFromParams(params, 38003)
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected threshold check to fail")
+	}
+}
+
+// Test for Solving with the value in the final partial block of the record
+func TestFixturePartialBlockSolving(t *testing.T) {
+
+	body := []byte(`{"status":"ok","amount":{"value":"38002"}}`)
+	for _, suite := range []uint16{record.TLS_AES_128_GCM_SHA256, record.TLS_CHACHA20_POLY1305_SHA256} {
+		fixture, err := newFixture(fixtureConfig{Body: body, CipherSuite: suite})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		params, err := fixture.Params(0, "value")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(params.PlainChunks)%32 == 0 {
+			t.Fatalf("%#04x: expected a partial final block, got %s", suite, params.PlainChunks)
+		}
+
+		circuit, assignment, err := origo.NewTls13OracleSuiteWrapperFromParams(params, 38001)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatalf("%#04x: %v", suite, err)
+		}
+
+		// bytes of the partial block are verified
+		tampered := assignment
+		tampered.PlainChunks = append([]frontend.Variable{}, assignment.PlainChunks...)
+		last := len(tampered.PlainChunks) - 1
+		tampered.PlainChunks[last] = (tampered.PlainChunks[last].(int) + 1) % 256
+		if err := test.IsSolved(&circuit, &tampered, ecc.BN254.ScalarField()); err == nil {
+			t.Fatalf("%#04x: expected tampered partial block to fail", suite)
+		}
+
+		if suite != record.TLS_AES_128_GCM_SHA256 {
+			continue
+		}
+
+		oracle, oracleAssignment, err := origo.NewTls13OracleWrapperFromParams(params, 38001)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := test.IsSolved(&oracle, &oracleAssignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatal(err)
+		}
+		ghash, ghashAssignment, err := origo.NewTls13OracleGHashWrapperFromParams(params, 38001)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := test.IsSolved(&ghash, &ghashAssignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// Test for Solving of responses with the value at different offsets by one oracle circuit
+func TestFixtureOracleOffsets(t *testing.T) {
+
+	bodies := []string{
+		`{"amount":{"value":"38002.20"},"currency":"EUR","status":"ok","id":7}`,
+		`{"currency":"EUR","amount":{"value":"38002.20"},"status":"ok","id":7}`,
+	}
+	var circuit origo.Tls13OracleWrapper
+	chunkIndices := map[int]bool{}
+	for i, body := range bodies {
+		fixture, err := newFixture(fixtureConfig{Body: []byte(body)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		params, err := fixture.Params(0, "value")
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, assignment, err := origo.NewTls13OracleWrapperFromParams(params, 38001)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunkIndices[params.ChunkIndex] = true
+
+		// the circuit of the first body
+		if i == 0 {
+			circuit = c
+		}
+		if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+			t.Fatal(i, err)
+		}
+
+		// the substring must be at its private offset
+		shifted := assignment
+		shifted.SubstringStart = shifted.SubstringStart.(int) + 1
+		shifted.ValueStart = shifted.ValueStart.(int) + 1
+		if err := test.IsSolved(&circuit, &shifted, ecc.BN254.ScalarField()); err == nil {
+			t.Fatal(i, "expected shifted substring to fail")
+		}
+	}
+	if len(chunkIndices) != len(bodies) {
+		t.Fatal("expected the values in different chunks")
+	}
+}
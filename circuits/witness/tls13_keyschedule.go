@@ -0,0 +1,133 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package witness
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"hash"
+)
+
+// key schedule hash of a cipher suite
+type suiteHash struct {
+	new       func() hash.Hash
+	blockSize int
+	stateSize int    // chaining value size
+	magic     string // encoding.BinaryMarshaler prefix
+	keyLen    int    // traffic key size
+}
+
+var (
+	sha256Suite = suiteHash{new: sha256.New, blockSize: 64, stateSize: 32, magic: "sha\x03", keyLen: 16}
+	sha384Suite = suiteHash{new: sha512.New384, blockSize: 128, stateSize: 64, magic: "sha\x04", keyLen: 32}
+	chachaSuite = suiteHash{new: sha256.New, blockSize: 64, stateSize: 32, magic: "sha\x03", keyLen: 32}
+)
+
+func (h suiteHash) size() int {
+	return h.new().Size()
+}
+
+// tls 1.3 HkdfLabel structure, rfc8446 section 7.1
+func hkdfLabel(label string, context []byte, length int) []byte {
+	b := make([]byte, 0, 2+1+6+len(label)+1+len(context))
+	b = binary.BigEndian.AppendUint16(b, uint16(length))
+	b = append(b, byte(6+len(label)))
+	b = append(b, "tls13 "...)
+	b = append(b, label...)
+	b = append(b, byte(len(context)))
+	b = append(b, context...)
+	return b
+}
+
+// reference derivation as implemented by crypto/tls
+
+func (h suiteHash) extract(salt, ikm []byte) []byte {
+	mac := hmac.New(h.new, salt)
+	mac.Write(ikm)
+	return mac.Sum(nil)
+}
+
+// single block hkdf-expand, length <= hash size
+func (h suiteHash) expandLabel(secret []byte, label string, context []byte, length int) []byte {
+	mac := hmac.New(h.new, secret)
+	mac.Write(hkdfLabel(label, context, length))
+	mac.Write([]byte{1})
+	return mac.Sum(nil)[:length]
+}
+
+// circuit shaped derivation, split into the hmac inner hash and the state after the outer key block
+
+// hmac key block xor pad
+func (h suiteHash) padKey(key []byte, pad byte) []byte {
+	block := make([]byte, h.blockSize)
+	copy(block, key)
+	for i := range block {
+		block[i] ^= pad
+	}
+	return block
+}
+
+// hash((key ^ ipad) || msg)
+func (h suiteHash) innerHash(key, msg []byte) []byte {
+	d := h.new()
+	d.Write(h.padKey(key, 0x36))
+	d.Write(msg)
+	return d.Sum(nil)
+}
+
+// chaining value after compressing (key ^ opad)
+func (h suiteHash) outerState(key []byte) ([]byte, error) {
+	d := h.new()
+	d.Write(h.padKey(key, 0x5c))
+	state, err := d.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	// magic || h0..h7 || block || length
+	return state[len(h.magic) : len(h.magic)+h.stateSize], nil
+}
+
+// finishes the hash from a chaining value after one block
+func (h suiteHash) resume(state, tail []byte) ([]byte, error) {
+	if len(state) != h.stateSize {
+		return nil, errors.New("witness: invalid chaining value")
+	}
+	marshaled := make([]byte, 0, len(h.magic)+h.stateSize+h.blockSize+8)
+	marshaled = append(marshaled, h.magic...)
+	marshaled = append(marshaled, state...)
+	marshaled = append(marshaled, make([]byte, h.blockSize)...)
+	marshaled = binary.BigEndian.AppendUint64(marshaled, uint64(h.blockSize))
+
+	d := h.new()
+	if err := d.(encoding.BinaryUnmarshaler).UnmarshalBinary(marshaled); err != nil {
+		return nil, err
+	}
+	d.Write(tail)
+	return d.Sum(nil), nil
+}
+
+// hash((key ^ opad) || inner), computed the way the kdc circuit does
+func (h suiteHash) outerHash(key, inner []byte) []byte {
+	d := h.new()
+	d.Write(h.padKey(key, 0x5c))
+	d.Write(inner)
+	return d.Sum(nil)
+}
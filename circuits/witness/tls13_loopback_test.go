@@ -0,0 +1,516 @@
+package witness
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	origo "circuits/origo"
+	record "circuits/record"
+)
+
+var errFixture = errors.New("witness: tls fixture")
+
+// tls constants used by the fixture client
+const (
+	recordChangeCipherSpec = 0x14
+	recordAlert            = 0x15
+	recordHandshake        = 0x16
+
+	typeClientHello = 1
+	typeServerHello = 2
+	typeFinished    = 20
+
+	extSupportedGroups = 10
+	extSignatureAlgs   = 13
+	extSupportedVers   = 43
+	extKeyShare        = 51
+
+	groupX25519         = 0x001d
+	fixtureTimeout      = 10 * time.Second
+	maxFixtureRecordLen = 16384
+)
+
+// server side of a loopback session
+type fixtureConfig struct {
+	Body        []byte // application data written by the server after the handshake
+	RecordSize  int    // body bytes per application_data record, 0 writes the body as one record
+	CipherSuite uint16 // only suite offered by the client, zero offers TLS_AES_128_GCM_SHA256
+}
+
+// secrets and encrypted server records of a loopback tls 1.3 session
+type fixture struct {
+	CipherSuite     uint16
+	HandshakeSecret []byte   // not in the key log, derived from the ecdhe shared secret of the client
+	TranscriptHash  []byte   // sha256(ClientHello..server Finished)
+	ServerIv        []byte   // server_application_traffic iv of the logged traffic secret
+	Records         [][]byte // application_data records of the server, index is the sequence number
+	Plaintexts      [][]byte // inner plaintexts of Records, including the content type
+}
+
+// session of the record with the given sequence number
+func (f fixture) Session(seq uint64) Session {
+	return Session{
+		CipherSuite:     f.CipherSuite,
+		HandshakeSecret: f.HandshakeSecret,
+		TranscriptHash:  f.TranscriptHash,
+		ServerIv:        f.ServerIv,
+		Record:          f.Records[seq],
+		SequenceNumber:  seq,
+	}
+}
+
+// oracle parameters selecting the integer value of the json key in the given record
+func (f fixture) Params(seq uint64, key string) (origo.FinalParams, error) {
+	if seq >= uint64(len(f.Records)) {
+		return origo.FinalParams{}, fmt.Errorf("%w: no record with sequence number %d", errFixture, seq)
+	}
+	sel, err := selectJSONValue(f.Plaintexts[seq], key)
+	if err != nil {
+		return origo.FinalParams{}, err
+	}
+	return Build(f.Session(seq), sel)
+}
+
+// selects "key" as substring and the integer digits of its value, e.g. 38002 of "key":"38002.20"
+func selectJSONValue(plaintext []byte, key string) (Selection, error) {
+
+	substring := []byte(`"` + key + `"`)
+	start := bytes.Index(plaintext, substring)
+	if start < 0 {
+		return Selection{}, fmt.Errorf("%w: key %s not found", ErrSelection, key)
+	}
+	end := start + len(substring)
+
+	valueStart := end
+	if valueStart < len(plaintext) && plaintext[valueStart] == ':' {
+		valueStart++
+	}
+	if valueStart < len(plaintext) && plaintext[valueStart] == '"' {
+		valueStart++
+	}
+	valueEnd := valueStart
+	for valueEnd < len(plaintext) && plaintext[valueEnd] >= '0' && plaintext[valueEnd] <= '9' {
+		valueEnd++
+	}
+	if valueEnd == valueStart {
+		return Selection{}, fmt.Errorf("%w: value of %s is not an integer", ErrSelection, key)
+	}
+
+	return Selection{
+		SubstringStart: start,
+		SubstringEnd:   end,
+		ValueStart:     valueStart,
+		ValueEnd:       valueEnd,
+	}, nil
+}
+
+// runs a crypto/tls server on loopback and records the session with a minimal single suite client
+func newFixture(config fixtureConfig) (fixture, error) {
+
+	cert, err := selfSignedCertificate()
+	if err != nil {
+		return fixture{}, err
+	}
+
+	keyLog := &syncBuffer{}
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates:                []tls.Certificate{cert},
+		MinVersion:                  tls.VersionTLS13,
+		KeyLogWriter:                keyLog,
+		SessionTicketsDisabled:      true,
+		DynamicRecordSizingDisabled: true,
+	})
+	if err != nil {
+		return fixture{}, err
+	}
+	defer listener.Close()
+
+	served := make(chan error, 1)
+	go func() {
+		served <- serve(listener, config)
+	}()
+
+	conn, err := net.DialTimeout("tcp", listener.Addr().String(), fixtureTimeout)
+	if err != nil {
+		return fixture{}, err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(fixtureTimeout)); err != nil {
+		return fixture{}, err
+	}
+
+	suite := config.CipherSuite
+	if suite == 0 {
+		suite = record.TLS_AES_128_GCM_SHA256
+	}
+	f, err := runClient(conn, keyLog, suite)
+	if err != nil {
+		return fixture{}, err
+	}
+	if err := <-served; err != nil {
+		return fixture{}, err
+	}
+	return f, nil
+}
+
+// writes the body in records of config.RecordSize and closes the connection
+func serve(listener net.Listener, config fixtureConfig) error {
+
+	conn, err := listener.Accept()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(time.Now().Add(fixtureTimeout)); err != nil {
+		return err
+	}
+	if err := conn.(*tls.Conn).Handshake(); err != nil {
+		return err
+	}
+
+	size := config.RecordSize
+	if size <= 0 || size > maxFixtureRecordLen {
+		size = maxFixtureRecordLen
+	}
+	for body := config.Body; len(body) > 0; {
+		n := min(size, len(body))
+		if _, err := conn.Write(body[:n]); err != nil {
+			return err
+		}
+		body = body[n:]
+	}
+	return nil
+}
+
+// performs the handshake and collects all server application_data records until close_notify.
+// traffic secrets are taken from the key log of the server.
+func runClient(conn net.Conn, keyLog *syncBuffer, suite uint16) (fixture, error) {
+
+	res := fixture{CipherSuite: suite}
+	r := bufio.NewReader(conn)
+
+	// ClientHello
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return res, err
+	}
+	clientRandom := make([]byte, 32)
+	if _, err := rand.Read(clientRandom); err != nil {
+		return res, err
+	}
+	clientHello := newClientHello(clientRandom, priv.PublicKey().Bytes(), suite)
+	if _, err := conn.Write(plaintextRecord(recordHandshake, clientHello)); err != nil {
+		return res, err
+	}
+	transcript := sha256.New()
+	transcript.Write(clientHello)
+
+	// ServerHello
+	typ, body, err := readRecord(r)
+	if err != nil {
+		return res, err
+	}
+	if typ != recordHandshake || len(body) < 4 || body[0] != typeServerHello {
+		return res, fmt.Errorf("%w: expected ServerHello", errFixture)
+	}
+	serverShare, err := parseServerHello(body[4:], suite)
+	if err != nil {
+		return res, err
+	}
+	transcript.Write(body)
+
+	// handshake secret, the oracle input the key log does not contain
+	peer, err := ecdh.X25519().NewPublicKey(serverShare)
+	if err != nil {
+		return res, err
+	}
+	shared, err := priv.ECDH(peer)
+	if err != nil {
+		return res, err
+	}
+	emptyHash := sha256.Sum256(nil)
+	earlySecret := sha256Suite.extract(make([]byte, 32), make([]byte, 32))
+	res.HandshakeSecret = sha256Suite.extract(sha256Suite.expandLabel(earlySecret, "derived", emptyHash[:], 32), shared)
+
+	// the server logs all secrets before it flushes its first flight
+	serverHsSecret, err := keyLog.secret("SERVER_HANDSHAKE_TRAFFIC_SECRET", clientRandom)
+	if err != nil {
+		return res, err
+	}
+	clientHsSecret, err := keyLog.secret("CLIENT_HANDSHAKE_TRAFFIC_SECRET", clientRandom)
+	if err != nil {
+		return res, err
+	}
+	serverAppSecret, err := keyLog.secret("SERVER_TRAFFIC_SECRET_0", clientRandom)
+	if err != nil {
+		return res, err
+	}
+
+	// encrypted server handshake up to Finished
+	serverHs := newTrafficState(suite, serverHsSecret)
+	var pending []byte
+	for finished := false; !finished; {
+		typ, body, err := readRecord(r)
+		if err != nil {
+			return res, err
+		}
+		if typ == recordChangeCipherSpec {
+			continue
+		}
+		inner, contentType, err := serverHs.open(typ, body)
+		if err != nil {
+			return res, err
+		}
+		if contentType != recordHandshake {
+			return res, fmt.Errorf("%w: unexpected content type %d during handshake", errFixture, contentType)
+		}
+		pending = append(pending, inner...)
+		for len(pending) >= 4 {
+			n := 4 + (int(pending[1])<<16 | int(pending[2])<<8 | int(pending[3]))
+			if len(pending) < n {
+				break
+			}
+			transcript.Write(pending[:n])
+			finished = pending[0] == typeFinished
+			pending = pending[n:]
+		}
+	}
+	res.TranscriptHash = transcript.Sum(nil)
+	res.ServerIv = sha256Suite.expandLabel(serverAppSecret, "iv", nil, 12)
+
+	// client Finished
+	finished := append([]byte{typeFinished, 0, 0, 32}, finishedMAC(clientHsSecret, res.TranscriptHash)...)
+	clientHs := newTrafficState(suite, clientHsSecret)
+	if _, err := conn.Write(clientHs.seal(recordHandshake, finished)); err != nil {
+		return res, err
+	}
+
+	// server application data until close_notify
+	serverApp := newTrafficState(suite, serverAppSecret)
+	for {
+		typ, body, err := readRecord(r)
+		if err != nil {
+			return res, err
+		}
+		record := append(recordHeader(typ, len(body)), body...)
+		inner, contentType, err := serverApp.open(typ, body)
+		if err != nil {
+			return res, err
+		}
+		if contentType == recordAlert {
+			break
+		}
+		if contentType != applicationData {
+			return res, fmt.Errorf("%w: unexpected content type %d after handshake", errFixture, contentType)
+		}
+		res.Records = append(res.Records, record)
+		res.Plaintexts = append(res.Plaintexts, append(inner, contentType))
+	}
+
+	return res, nil
+}
+
+// ClientHello offering the given cipher suite with an x25519 key share only
+func newClientHello(random, share []byte, suite uint16) []byte {
+
+	var ext []byte
+	ext = appendExtension(ext, extSupportedVers, []byte{2, 0x03, 0x04})
+	ext = appendExtension(ext, extSupportedGroups, []byte{0, 2, 0x00, 0x1d})
+	ext = appendExtension(ext, extSignatureAlgs, []byte{0, 6, 0x04, 0x03, 0x08, 0x04, 0x08, 0x07})
+	keyShare := binary.BigEndian.AppendUint16(nil, uint16(4+len(share)))
+	keyShare = binary.BigEndian.AppendUint16(keyShare, groupX25519)
+	keyShare = binary.BigEndian.AppendUint16(keyShare, uint16(len(share)))
+	ext = appendExtension(ext, extKeyShare, append(keyShare, share...))
+
+	body := []byte{0x03, 0x03}
+	body = append(body, random...)
+	body = append(body, 0)                                 // legacy_session_id
+	body = append(body, 0, 2, byte(suite>>8), byte(suite)) // cipher_suites
+	body = append(body, 1, 0)                              // legacy_compression_methods
+	body = binary.BigEndian.AppendUint16(body, uint16(len(ext)))
+	body = append(body, ext...)
+
+	msg := []byte{typeClientHello, byte(len(body) >> 16), byte(len(body) >> 8), byte(len(body))}
+	return append(msg, body...)
+}
+
+func appendExtension(b []byte, typ uint16, data []byte) []byte {
+	b = binary.BigEndian.AppendUint16(b, typ)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(data)))
+	return append(b, data...)
+}
+
+// returns the x25519 key share of the server
+func parseServerHello(body []byte, suite uint16) ([]byte, error) {
+
+	malformed := fmt.Errorf("%w: malformed ServerHello", errFixture)
+	if len(body) < 2+32+1 {
+		return nil, malformed
+	}
+	body = body[2+32:]
+	sessionIdLen := int(body[0])
+	if len(body) < 1+sessionIdLen+2+1+2 {
+		return nil, malformed
+	}
+	body = body[1+sessionIdLen:]
+	if binary.BigEndian.Uint16(body) != suite {
+		return nil, fmt.Errorf("%w: server selected cipher suite %#04x", errFixture, binary.BigEndian.Uint16(body))
+	}
+	body = body[2+1+2:]
+
+	for len(body) >= 4 {
+		typ := binary.BigEndian.Uint16(body)
+		n := int(binary.BigEndian.Uint16(body[2:]))
+		if len(body) < 4+n {
+			return nil, malformed
+		}
+		data := body[4 : 4+n]
+		body = body[4+n:]
+		if typ != extKeyShare {
+			continue
+		}
+		if len(data) < 4 || binary.BigEndian.Uint16(data) != groupX25519 || int(binary.BigEndian.Uint16(data[2:])) != len(data)-4 {
+			return nil, malformed
+		}
+		return data[4:], nil
+	}
+	return nil, fmt.Errorf("%w: ServerHello without key share", errFixture)
+}
+
+// finished verify_data, rfc8446 section 4.4.4
+func finishedMAC(secret, transcriptHash []byte) []byte {
+	mac := hmac.New(sha256.New, sha256Suite.expandLabel(secret, "finished", nil, 32))
+	mac.Write(transcriptHash)
+	return mac.Sum(nil)
+}
+
+// aead, iv and sequence number of one traffic secret
+type trafficState struct {
+	aead cipher.AEAD
+	iv   []byte
+	seq  uint64
+}
+
+func newTrafficState(suite uint16, secret []byte) *trafficState {
+	keyLen := 16
+	if suite == record.TLS_CHACHA20_POLY1305_SHA256 {
+		keyLen = 32
+	}
+	aead, _ := newAEAD(suite, sha256Suite.expandLabel(secret, "key", nil, keyLen))
+	return &trafficState{aead: aead, iv: sha256Suite.expandLabel(secret, "iv", nil, 12)}
+}
+
+// decrypts a protected record and splits off the inner content type
+func (t *trafficState) open(typ byte, body []byte) ([]byte, byte, error) {
+	if typ != applicationData {
+		return nil, 0, fmt.Errorf("%w: unprotected record of type %d", errFixture, typ)
+	}
+	inner, err := t.aead.Open(nil, recordNonce(t.iv, t.seq), body, recordHeader(typ, len(body)))
+	if err != nil {
+		return nil, 0, ErrDecrypt
+	}
+	t.seq++
+	i := len(inner) - 1
+	for i >= 0 && inner[i] == 0 {
+		i--
+	}
+	if i < 0 {
+		return nil, 0, fmt.Errorf("%w: record without content type", errFixture)
+	}
+	return inner[:i], inner[i], nil
+}
+
+// protects a record with the given inner content type
+func (t *trafficState) seal(contentType byte, data []byte) []byte {
+	inner := append(append([]byte{}, data...), contentType)
+	header := recordHeader(applicationData, len(inner)+t.aead.Overhead())
+	record := t.aead.Seal(header, recordNonce(t.iv, t.seq), inner, header)
+	t.seq++
+	return record
+}
+
+func recordHeader(typ byte, length int) []byte {
+	return []byte{typ, 0x03, 0x03, byte(length >> 8), byte(length)}
+}
+
+func plaintextRecord(typ byte, data []byte) []byte {
+	record := recordHeader(typ, len(data))
+	record[2] = 0x01 // legacy_record_version of the ClientHello
+	return append(record, data...)
+}
+
+func readRecord(r io.Reader) (byte, []byte, error) {
+	header := make([]byte, recordHeaderLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, nil, err
+	}
+	body := make([]byte, binary.BigEndian.Uint16(header[3:]))
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header[0], body, nil
+}
+
+// ephemeral ecdsa p256 certificate for the loopback server
+func selfSignedCertificate() (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "origo fixture"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
+
+// key log written concurrently by the server
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// looks up a secret in NSS key log format
+func (b *syncBuffer) secret(label string, clientRandom []byte) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	prefix := label + " " + hex.EncodeToString(clientRandom) + " "
+	for _, line := range strings.Split(b.buf.String(), "\n") {
+		if strings.HasPrefix(line, prefix) {
+			return hex.DecodeString(strings.TrimPrefix(line, prefix))
+		}
+	}
+	return nil, fmt.Errorf("%w: %s missing in key log", errFixture, label)
+}
@@ -0,0 +1,308 @@
+/*
+Copyright 2023 Jan Lauinger
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package witness
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	origo "circuits/origo"
+	record "circuits/record"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+var (
+	ErrKeySchedule = errors.New("witness: circuit shaped key schedule differs from hkdf derivation")
+	ErrIvMismatch  = errors.New("witness: derived server application iv differs from the given iv")
+	ErrDecrypt     = errors.New("witness: record does not decrypt under the derived traffic key")
+	ErrSelection   = errors.New("witness: invalid plaintext selection")
+)
+
+// tls record header size and application_data content type
+const (
+	recordHeaderLen = 5
+	applicationData = 0x17
+)
+
+// host side view of a tls 1.3 session with TLS_AES_128_GCM_SHA256, TLS_AES_256_GCM_SHA384 or TLS_CHACHA20_POLY1305_SHA256
+type Session struct {
+	CipherSuite     uint16 // negotiated cipher suite, zero selects TLS_AES_128_GCM_SHA256
+	HandshakeSecret []byte // handshake secret of the suite hash length
+	TranscriptHash  []byte // hash(ClientHello..server Finished)
+	ServerIv        []byte // 12 byte server_application_traffic iv as used by the host
+	Record          []byte // encrypted record including 5 byte header and 16 byte tag
+	SequenceNumber  uint64 // record sequence number under the server application traffic key
+}
+
+// absolute offsets into the decrypted record plaintext
+type Selection struct {
+	SubstringStart int // start of the public substring, e.g. the json key "price"
+	SubstringEnd   int
+	ValueStart     int // start of the value compared against the threshold
+	ValueEnd       int
+}
+
+// key schedule outputs and the sha256 intermediate values the kdc circuit consumes
+type keySchedule struct {
+	intermediateHashHSopad []byte
+	dHSin                  []byte
+	dHS                    []byte
+	MSin                   []byte
+	MS                     []byte
+	SATSin                 []byte
+	SATS                   []byte
+	CATSin                 []byte
+	CATS                   []byte
+	tkSAPPin               []byte
+	tkSAPP                 []byte
+	tkCAPPin               []byte
+	ivSapp                 []byte
+	ivCapp                 []byte
+}
+
+// derives the server traffic key in the shape of kdc.Tls13Kdc or kdc.Tls13Kdc384 and cross-checks it against hkdf
+func deriveKeySchedule(h suiteHash, hs, transcriptHash []byte) (keySchedule, error) {
+
+	var ks keySchedule
+	var err error
+
+	emptyHash := h.new().Sum(nil)
+	zeros := make([]byte, h.size())
+
+	// dHS = HKDF-Expand-Label(HS, "derived", "", Hash.length), outer hash resumed from the opad state
+	ks.dHSin = h.innerHash(hs, append(hkdfLabel("derived", emptyHash, h.size()), 1))
+	ks.intermediateHashHSopad, err = h.outerState(hs)
+	if err != nil {
+		return ks, err
+	}
+	ks.dHS, err = h.resume(ks.intermediateHashHSopad, ks.dHSin)
+	if err != nil {
+		return ks, err
+	}
+
+	// MS = HKDF-Extract(dHS, 0)
+	ks.MSin = h.innerHash(ks.dHS, zeros)
+	ks.MS = h.outerHash(ks.dHS, ks.MSin)
+
+	// SATS and CATS = Derive-Secret(MS, "s/c ap traffic", CH..SF)
+	ks.SATSin = h.innerHash(ks.MS, append(hkdfLabel("s ap traffic", transcriptHash, h.size()), 1))
+	ks.SATS = h.outerHash(ks.MS, ks.SATSin)
+	ks.CATSin = h.innerHash(ks.MS, append(hkdfLabel("c ap traffic", transcriptHash, h.size()), 1))
+	ks.CATS = h.outerHash(ks.MS, ks.CATSin)
+
+	// traffic keys = HKDF-Expand-Label(XATS, "key", "", key length)
+	ks.tkSAPPin = h.innerHash(ks.SATS, append(hkdfLabel("key", nil, h.keyLen), 1))
+	ks.tkSAPP = h.outerHash(ks.SATS, ks.tkSAPPin)[:h.keyLen]
+	ks.tkCAPPin = h.innerHash(ks.CATS, append(hkdfLabel("key", nil, h.keyLen), 1))
+
+	// traffic ivs = HKDF-Expand-Label(XATS, "iv", "", 12)
+	ks.ivSapp = h.expandLabel(ks.SATS, "iv", nil, 12)
+	ks.ivCapp = h.expandLabel(ks.CATS, "iv", nil, 12)
+
+	// cross-check against the crypto/tls style derivation
+	dHS := h.expandLabel(hs, "derived", emptyHash, h.size())
+	MS := h.extract(dHS, zeros)
+	SATS := h.expandLabel(MS, "s ap traffic", transcriptHash, h.size())
+	key := h.expandLabel(SATS, "key", nil, h.keyLen)
+	if !bytes.Equal(dHS, ks.dHS) || !bytes.Equal(MS, ks.MS) || !bytes.Equal(SATS, ks.SATS) || !bytes.Equal(key, ks.tkSAPP) {
+		return ks, ErrKeySchedule
+	}
+
+	return ks, nil
+}
+
+// nonce = iv xor padded sequence number, rfc8446 section 5.3
+func recordNonce(iv []byte, seq uint64) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, iv)
+	var seqBytes [8]byte
+	binary.BigEndian.PutUint64(seqBytes[:], seq)
+	for i := 0; i < 8; i++ {
+		nonce[4+i] ^= seqBytes[i]
+	}
+	return nonce
+}
+
+// record aead of the cipher suite
+func newAEAD(suite uint16, key []byte) (cipher.AEAD, error) {
+	if suite == record.TLS_CHACHA20_POLY1305_SHA256 {
+		return chacha20poly1305.New(key)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// decrypts the record and returns the inner plaintext
+func openRecord(suite uint16, key, iv []byte, seq uint64, record []byte) ([]byte, error) {
+
+	if len(record) < recordHeaderLen+16 || record[0] != applicationData {
+		return nil, fmt.Errorf("%w: not an application_data record", ErrDecrypt)
+	}
+	if int(binary.BigEndian.Uint16(record[3:5])) != len(record)-recordHeaderLen {
+		return nil, fmt.Errorf("%w: record length mismatch", ErrDecrypt)
+	}
+
+	aead, err := newAEAD(suite, key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, recordNonce(iv, seq), record[recordHeaderLen:], record[:recordHeaderLen])
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+func encryptBlock(key, pt []byte) []byte {
+	block, _ := aes.NewCipher(key)
+	out := make([]byte, 16)
+	block.Encrypt(out, pt)
+	return out
+}
+
+// derives all oracle parameters of a session and a plaintext selection, ECB0 is the tag mask of the record at its sequence number
+func Build(s Session, sel Selection) (origo.FinalParams, error) {
+
+	suite := s.CipherSuite
+	if suite == 0 {
+		suite = record.TLS_AES_128_GCM_SHA256
+	}
+	var h suiteHash
+	switch suite {
+	case record.TLS_AES_128_GCM_SHA256:
+		h = sha256Suite
+	case record.TLS_AES_256_GCM_SHA384:
+		h = sha384Suite
+	case record.TLS_CHACHA20_POLY1305_SHA256:
+		h = chachaSuite
+	default:
+		return origo.FinalParams{}, fmt.Errorf("witness: unsupported cipher suite %#04x", suite)
+	}
+
+	if len(s.HandshakeSecret) != h.size() || len(s.TranscriptHash) != h.size() || len(s.ServerIv) != 12 {
+		return origo.FinalParams{}, fmt.Errorf("witness: handshake secret and transcript hash must be %d bytes, iv 12 bytes", h.size())
+	}
+
+	ks, err := deriveKeySchedule(h, s.HandshakeSecret, s.TranscriptHash)
+	if err != nil {
+		return origo.FinalParams{}, err
+	}
+	if !bytes.Equal(ks.ivSapp, s.ServerIv) {
+		return origo.FinalParams{}, ErrIvMismatch
+	}
+
+	plaintext, err := openRecord(suite, ks.tkSAPP, ks.ivSapp, s.SequenceNumber, s.Record)
+	if err != nil {
+		return origo.FinalParams{}, err
+	}
+
+	if sel.SubstringStart < 0 || sel.SubstringStart >= sel.SubstringEnd || sel.SubstringEnd > sel.ValueStart || sel.ValueStart >= sel.ValueEnd || sel.ValueEnd >= len(plaintext) {
+		return origo.FinalParams{}, ErrSelection
+	}
+	// gcm data blocks of 16 bytes start at counter 2, counter 1 is reserved for the tag.
+	// chacha20 blocks of 64 bytes start at counter 1, block 0 is reserved for the poly1305 key.
+	blockSize, firstCounter := 16, 2
+	if suite == record.TLS_CHACHA20_POLY1305_SHA256 {
+		blockSize, firstCounter = 64, 1
+	}
+	// the chunks end with the block of the byte after the value, the circuit checks that it terminates the value
+	firstBlock := sel.SubstringStart / blockSize
+	lastBlock := sel.ValueEnd / blockSize
+	offset := firstBlock * blockSize
+	// the final block of the record may be partial
+	end := min((lastBlock+1)*blockSize, len(plaintext))
+	plainChunks := plaintext[offset:end]
+	body := s.Record[recordHeaderLen:]
+	cipherChunks := body[offset:end]
+
+	var seq [8]byte
+	binary.BigEndian.PutUint64(seq[:], s.SequenceNumber)
+
+	// authtag blocks of gcm, ECB0 is the tag mask E(K, J0) with J0 the record nonce and counter 1
+	var ecb0, ecbk []byte
+	if suite != record.TLS_CHACHA20_POLY1305_SHA256 {
+		ivCounter := append(recordNonce(ks.ivSapp, s.SequenceNumber), 0, 0, 0, 1)
+		ecb0 = encryptBlock(ks.tkSAPP, ivCounter)
+		ecbk = encryptBlock(ks.tkSAPP, make([]byte, 16))
+	}
+
+	return origo.FinalParams{
+		CATSin:                 hex.EncodeToString(ks.CATSin),
+		ECB0:                   hex.EncodeToString(ecb0),
+		ECBK:                   hex.EncodeToString(ecbk),
+		MSin:                   hex.EncodeToString(ks.MSin),
+		SATSin:                 hex.EncodeToString(ks.SATSin),
+		ChunkIndex:             firstBlock + firstCounter,
+		CipherChunks:           hex.EncodeToString(cipherChunks),
+		DHSin:                  hex.EncodeToString(ks.dHSin),
+		IntermediateHashHSopad: hex.EncodeToString(ks.intermediateHashHSopad),
+		IvCapp:                 hex.EncodeToString(ks.ivCapp),
+		IvSapp:                 hex.EncodeToString(ks.ivSapp),
+		NumberChunks:           lastBlock - firstBlock + 1,
+		PlainChunks:            hex.EncodeToString(plainChunks),
+		SizeValue:              sel.ValueEnd - sel.ValueStart,
+		Substring:              string(plaintext[sel.SubstringStart:sel.SubstringEnd]),
+		SubstringStart:         sel.SubstringStart - offset,
+		SubstringEnd:           sel.SubstringEnd - offset,
+		SubstringStartIdx:      sel.SubstringStart,
+		TkCAPPin:               hex.EncodeToString(ks.tkCAPPin),
+		TkSAPPin:               hex.EncodeToString(ks.tkSAPPin),
+		ValueStart:             sel.ValueStart - offset,
+		ValueEnd:               sel.ValueEnd - offset,
+		SequenceNumber:         hex.EncodeToString(seq[:]),
+		RecordHeader:           hex.EncodeToString(s.Record[:recordHeaderLen]),
+		Ciphertext:             hex.EncodeToString(body[:len(body)-16]),
+		Tag:                    hex.EncodeToString(body[len(body)-16:]),
+		CipherSuite:            suite,
+	}, nil
+}
+
+// returns circuit and ready-to-prove assignment of the oracle circuit for a session
+func NewTls13OracleWrapper(s Session, sel Selection, threshold int) (origo.Tls13OracleWrapper, origo.Tls13OracleWrapper, error) {
+	params, err := Build(s, sel)
+	if err != nil {
+		return origo.Tls13OracleWrapper{}, origo.Tls13OracleWrapper{}, err
+	}
+	return origo.NewTls13OracleWrapperFromParams(params, threshold)
+}
+
+// returns circuit and ready-to-prove assignment of the oracle circuit with in-circuit ghash for a session
+func NewTls13OracleGHashWrapper(s Session, sel Selection, threshold int) (origo.Tls13OracleGHashWrapper, origo.Tls13OracleGHashWrapper, error) {
+	params, err := Build(s, sel)
+	if err != nil {
+		return origo.Tls13OracleGHashWrapper{}, origo.Tls13OracleGHashWrapper{}, err
+	}
+	return origo.NewTls13OracleGHashWrapperFromParams(params, threshold)
+}
+
+// returns circuit and ready-to-prove assignment of the cipher suite parameterized oracle circuit for a session
+func NewTls13OracleSuiteWrapper(s Session, sel Selection, threshold int) (origo.Tls13OracleSuiteWrapper, origo.Tls13OracleSuiteWrapper, error) {
+	params, err := Build(s, sel)
+	if err != nil {
+		return origo.Tls13OracleSuiteWrapper{}, origo.Tls13OracleSuiteWrapper{}, err
+	}
+	return origo.NewTls13OracleSuiteWrapperFromParams(params, threshold)
+}
@@ -0,0 +1,208 @@
+package witness
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	record "circuits/record"
+	utils "circuits/utils"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/test"
+)
+
+const witnessBody = `{"data":{"currency":"EUR","amount":{"value":"38002.20"}},"status":"ok"}`
+
+// reference server application traffic key and iv
+func referenceTrafficKeys(h suiteHash, hs, transcriptHash []byte) ([]byte, []byte) {
+	emptyHash := h.new().Sum(nil)
+	dHS := h.expandLabel(hs, "derived", emptyHash, h.size())
+	MS := h.extract(dHS, make([]byte, h.size()))
+	SATS := h.expandLabel(MS, "s ap traffic", transcriptHash, h.size())
+	return h.expandLabel(SATS, "key", nil, h.keyLen), h.expandLabel(SATS, "iv", nil, 12)
+}
+
+// encrypts body as one application_data record of the server
+func setupSession(seq uint64) (Session, Selection) {
+	hs := utils.MustHex("8a0b7d4b6c2c0f2d91a3c6e5a4e1f3b27c9c2ae5fb0c6d1e8d3a4b5c6d7e8f90")
+	return setupSuiteSession(record.TLS_AES_128_GCM_SHA256, sha256Suite, hs, seq)
+}
+
+// same session negotiated with TLS_AES_256_GCM_SHA384
+func setupSession384(seq uint64) (Session, Selection) {
+	hs := utils.MustHex("8a0b7d4b6c2c0f2d91a3c6e5a4e1f3b27c9c2ae5fb0c6d1e8d3a4b5c6d7e8f9011223344556677889900aabbccddeeff")
+	return setupSuiteSession(record.TLS_AES_256_GCM_SHA384, sha384Suite, hs, seq)
+}
+
+func setupSuiteSession(suite uint16, h suiteHash, hs []byte, seq uint64) (Session, Selection) {
+
+	th := h.new()
+	th.Write([]byte("ClientHello..server Finished"))
+	transcriptHash := th.Sum(nil)
+	key, iv := referenceTrafficKeys(h, hs, transcriptHash)
+
+	// inner plaintext with content type
+	inner := append([]byte(witnessBody), applicationData)
+	header := []byte{applicationData, 0x03, 0x03, 0, 0}
+	binary.BigEndian.PutUint16(header[3:], uint16(len(inner)+16))
+
+	block, _ := aes.NewCipher(key)
+	aead, _ := cipher.NewGCM(block)
+	sealed := aead.Seal(append([]byte{}, header...), recordNonce(iv, seq), inner, header)
+
+	substringStart := bytes.Index([]byte(witnessBody), []byte(`"value"`))
+	valueStart := substringStart + len(`"value":"`)
+
+	session := Session{
+		CipherSuite:     suite,
+		HandshakeSecret: hs,
+		TranscriptHash:  transcriptHash,
+		ServerIv:        iv,
+		Record:          sealed,
+		SequenceNumber:  seq,
+	}
+	selection := Selection{
+		SubstringStart: substringStart,
+		SubstringEnd:   substringStart + len(`"value"`),
+		ValueStart:     valueStart,
+		ValueEnd:       valueStart + len("38002"),
+	}
+	return session, selection
+}
+
+func TestBuild(t *testing.T) {
+	session, selection := setupSession(1)
+
+	params, err := Build(session, selection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Substring != `"value"` || params.ChunkIndex != selection.SubstringStart/16+2 {
+		t.Fatalf("unexpected parameters: %+v", params)
+	}
+	if params.SequenceNumber != "0000000000000001" {
+		t.Fatalf("unexpected sequence number %s", params.SequenceNumber)
+	}
+}
+
+func TestBuildRejectsWrongInputs(t *testing.T) {
+	session, selection := setupSession(1)
+
+	wrongIv := session
+	wrongIv.ServerIv = make([]byte, 12)
+	if _, err := Build(wrongIv, selection); !errors.Is(err, ErrIvMismatch) {
+		t.Fatalf("expected ErrIvMismatch, got %v", err)
+	}
+
+	wrongTranscript := session
+	wrongTranscript.TranscriptHash = make([]byte, 32)
+	_, wrongTranscript.ServerIv = referenceTrafficKeys(sha256Suite, session.HandshakeSecret, wrongTranscript.TranscriptHash)
+	if _, err := Build(wrongTranscript, selection); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("expected ErrDecrypt, got %v", err)
+	}
+
+	wrongSeq := session
+	wrongSeq.SequenceNumber = 2
+	if _, err := Build(wrongSeq, selection); !errors.Is(err, ErrDecrypt) {
+		t.Fatalf("expected ErrDecrypt, got %v", err)
+	}
+
+	// the inner plaintext holds the body and the content type
+	outside := selection
+	outside.ValueEnd = len(witnessBody) + 2
+	if _, err := Build(session, outside); !errors.Is(err, ErrSelection) {
+		t.Fatalf("expected ErrSelection, got %v", err)
+	}
+}
+
+// Test for Solving
+func TestTls13OracleWrapperSolving(t *testing.T) {
+	session, selection := setupSession(1)
+
+	circuit, assignment, err := NewTls13OracleWrapper(session, selection, 38001)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// the public iv must match the iv derived from the handshake
+func TestTls13OracleWrapperRejectsForeignIv(t *testing.T) {
+	session, selection := setupSession(1)
+
+	circuit, assignment, err := NewTls13OracleWrapper(session, selection, 38001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignment.Iv[0] = (assignment.Iv[0].(int) + 1) % 256
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected foreign iv to fail")
+	}
+}
+
+// Test for Solving with the record tag verified in-circuit
+func TestTls13OracleGHashWrapperSolving(t *testing.T) {
+	session, selection := setupSession(1)
+
+	circuit, assignment, err := NewTls13OracleGHashWrapper(session, selection, 38001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// tampered ciphertext outside of the selected chunks
+	assignment.Ciphertext[0] = (assignment.Ciphertext[0].(int) + 1) % 256
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected tampered ciphertext to fail")
+	}
+}
+
+// Test for Solving of a TLS_AES_256_GCM_SHA384 session
+func TestTls13OracleSuiteWrapperSolving(t *testing.T) {
+	session, selection := setupSession384(1)
+
+	circuit, assignment, err := NewTls13OracleSuiteWrapper(session, selection, 38001)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err != nil {
+		t.Fatal(err)
+	}
+
+	// value below threshold
+	_, assignment, _ = NewTls13OracleSuiteWrapper(session, selection, 38003)
+	if err := test.IsSolved(&circuit, &assignment, ecc.BN254.ScalarField()); err == nil {
+		t.Fatal("expected threshold check to fail")
+	}
+}
+
+// ECB0 is the gcm tag mask E(K, J0) of the record, a seal of an empty message and aad is the bare mask
+func TestBuildTagMask(t *testing.T) {
+	for _, seq := range []uint64{0, 1, 0x01a2b3c4d5e6f708} {
+		session, selection := setupSession(seq)
+
+		params, err := Build(session, selection)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		key, iv := referenceTrafficKeys(sha256Suite, session.HandshakeSecret, session.TranscriptHash)
+		block, _ := aes.NewCipher(key)
+		aead, _ := cipher.NewGCM(block)
+		mask := aead.Seal(nil, recordNonce(iv, seq), nil, nil)
+
+		if params.ECB0 != hex.EncodeToString(mask) {
+			t.Fatalf("seq %d: ECB0 %s differs from tag mask %x", seq, params.ECB0, mask)
+		}
+	}
+}